@@ -0,0 +1,73 @@
+package coa
+
+// AccountBuilder builds accounts fluently. Choosing one side of an exclusive
+// pair of tags, such as BalanceSheet and IncomeStatement, drops the other.
+type AccountBuilder struct {
+	account Account
+}
+
+func NewAccountBuilder() *AccountBuilder {
+	return &AccountBuilder{}
+}
+
+func (b *AccountBuilder) Number(number string) *AccountBuilder {
+	b.account.Number = number
+	return b
+}
+
+func (b *AccountBuilder) Name(name string) *AccountBuilder {
+	b.account.Name = name
+	return b
+}
+
+func (b *AccountBuilder) Parent(id string) *AccountBuilder {
+	b.account.Parent = id
+	return b
+}
+
+func (b *AccountBuilder) BalanceSheet() *AccountBuilder {
+	return b.exclusive("balanceSheet", "incomeStatement")
+}
+
+func (b *AccountBuilder) IncomeStatement() *AccountBuilder {
+	return b.exclusive("incomeStatement", "balanceSheet")
+}
+
+func (b *AccountBuilder) IncreaseOnDebit() *AccountBuilder {
+	return b.exclusive("increaseOnDebit", "increaseOnCredit")
+}
+
+func (b *AccountBuilder) IncreaseOnCredit() *AccountBuilder {
+	return b.exclusive("increaseOnCredit", "increaseOnDebit")
+}
+
+func (b *AccountBuilder) Detail() *AccountBuilder {
+	return b.exclusive("detail", "summary")
+}
+
+func (b *AccountBuilder) Summary() *AccountBuilder {
+	return b.exclusive("summary", "detail")
+}
+
+// Tag adds any other tag, such as an income statement attribute.
+func (b *AccountBuilder) Tag(tag string) *AccountBuilder {
+	if !b.account.Tags.Contains(tag) {
+		b.account.Tags = append(b.account.Tags, tag)
+	}
+	return b
+}
+
+func (b *AccountBuilder) exclusive(tag, other string) *AccountBuilder {
+	b.account.Tags = b.account.Tags.Without(Tags{other})
+	return b.Tag(tag)
+}
+
+// Build returns a new account, or a ValidationError when it breaks a rule
+// that doesn't depend on the rest of the chart.
+func (b *AccountBuilder) Build() (*Account, error) {
+	a := b.account.Clone()
+	if msg := a.localValidationMessage(); msg != "" {
+		return nil, ValidationError(msg)
+	}
+	return a, nil
+}
@@ -0,0 +1,28 @@
+package coa
+
+import "testing"
+
+func TestAccountBuilder(t *testing.T) {
+	a, err := NewAccountBuilder().Number("1").Name("Cash").IncomeStatement().BalanceSheet().IncreaseOnDebit().Detail().Build()
+	check(t, err)
+	if a.Number != "1" || a.Name != "Cash" || !a.Tags.Equal(Tags{"balanceSheet", "increaseOnDebit", "detail"}) {
+		t.Errorf("Unexpected account %v", a)
+	}
+	_, err = NewAccountBuilder().Number("1").Name("Cash").BalanceSheet().Build()
+	if err == nil || err.Error() != "The normal balance must be informed" {
+		t.Errorf("Expected a validation error but was %v", err)
+	}
+	b := NewAccountBuilder().Number("2").Name("Revenue").IncomeStatement().IncreaseOnCredit().Tag("operating")
+	a1, err := b.Build()
+	check(t, err)
+	a2, err := b.Parent("p").Build()
+	check(t, err)
+	if a1.Parent != "" || a2.Parent != "p" {
+		t.Errorf("Expected independent accounts but was %v and %v", a1.Parent, a2.Parent)
+	}
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, a1)
+	check(t, err)
+}
@@ -0,0 +1,11 @@
+package coa
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+}
+
+type wallClock struct{}
+
+func (wallClock) Now() time.Time { return time.Now() }
@@ -0,0 +1,25 @@
+package coa
+
+import (
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func TestRepositoryClock(t *testing.T) {
+	now := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := NewCoaRepositoryWithClock(store{}, fixedClock(now))
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if !coa.AsOf.Equal(now) || !coa.Created.Equal(now) {
+		t.Errorf("Expected %v but was %v %v", now, coa.AsOf, coa.Created)
+	}
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if !a.AsOf.Equal(now) || !a.Created.Equal(now) {
+		t.Errorf("Expected %v but was %v %v", now, a.AsOf, a.Created)
+	}
+}
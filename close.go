@@ -0,0 +1,19 @@
+package coa
+
+// Close releases what the repository holds in memory: the channels returned
+// by Subscribe are closed and no more events are published. The repository
+// writes through to the store, so there is nothing to flush, and the store
+// itself is left open. The repository, and the copies returned by ReadOnly,
+// must not be used after Close. Calling it again does nothing.
+//
+// A copy returned by ReadOnly shares the change feed of the repository it
+// came from, so closing the copy does nothing; close the original instead.
+func (r *CoaRepository) Close() error {
+	if _, ok := r.store.(readOnlyStore); ok {
+		return nil
+	}
+	if r.feed != nil {
+		r.feed.close()
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package coa
+
+import "testing"
+
+func TestClose(t *testing.T) {
+	r := NewCoaRepository(store{})
+	ch := r.Subscribe()
+	check(t, r.Close())
+	if _, ok := <-ch; ok {
+		t.Error("Expected the subscription to be closed")
+	}
+	if _, ok := <-r.Subscribe(); ok {
+		t.Error("Expected a subscription after Close to be closed")
+	}
+	check(t, r.Close())
+	check(t, (&CoaRepository{}).Close())
+}
+
+func TestCloseReadOnlyView(t *testing.T) {
+	r := NewCoaRepository(store{})
+	ch := r.Subscribe()
+	check(t, r.ReadOnly().Close())
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if e, ok := <-ch; !ok || e.Id != coa.Id {
+		t.Errorf("Expected the original subscription to stay open but was %v %v", e, ok)
+	}
+}
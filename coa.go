@@ -3,35 +3,48 @@
 package coa
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
 	"time"
 
-	uuid "github.com/satori/go.uuid"
 	"github.com/tinylib/msgp/msgp"
 )
 
 type ChartOfAccounts struct {
-	Id                      string    `json:"_id"`
-	Name                    string    `json:"name"`
-	RetainedEarningsAccount string    `json:"retainedEarningsAccount"`
-	User                    string    `json:"user"`
-	AsOf                    time.Time `json:"timestamp"`
-	Created                 time.Time `json:"-"`
-	Removed                 time.Time `json:"-"`
+	Id                      string     `json:"_id"`
+	Name                    string     `json:"name"`
+	RetainedEarningsAccount string     `json:"retainedEarningsAccount"`
+	User                    string     `json:"user"`
+	ModifiedBy              string     `json:"modifiedBy"`
+	AsOf                    time.Time  `json:"timestamp"`
+	Created                 time.Time  `json:"created"`
+	Removed                 time.Time  `json:"removed"`
+	Zones                   []TimeZone `json:"zones"`
 }
 
 type Account struct {
-	Id      string    `json:"_id"`
-	Number  string    `json:"number"`
-	Name    string    `json:"name"`
-	Tags    Tags      `json:"tags"`
-	Parent  string    `json:"parent"`
-	User    string    `json:"user"`
-	AsOf    time.Time `json:"timestamp"`
-	Created time.Time `json:"-"`
-	Removed time.Time `json:"-"`
+	Id          string     `json:"_id"`
+	Number      string     `json:"number"`
+	Name        string     `json:"name"`
+	Tags        Tags       `json:"tags"`
+	Parent      string     `json:"parent"`
+	User        string     `json:"user"`
+	ModifiedBy  string     `json:"modifiedBy"`
+	AsOf        time.Time  `json:"timestamp"`
+	Created     time.Time  `json:"created"`
+	Removed     time.Time  `json:"removed"`
+	Reactivated time.Time  `json:"reactivated"`
+	Zones       []TimeZone `json:"zones"`
+}
+
+// TimeZone is the zone a time was in when stored: its name and its offset,
+// in seconds east of UTC, at that time.
+type TimeZone struct {
+	Name   string `json:"name"`
+	Offset int    `json:"offset"`
 }
 
 type ChartsOfAccounts []*ChartOfAccounts
@@ -57,25 +70,225 @@ var nonInheritedProperties = map[string]string{
 	"summary":          "",
 }
 
+func InheritedProperties() map[string]string {
+	return copyProperties(inheritedProperties)
+}
+
+func NonInheritedProperties() map[string]string {
+	return copyProperties(nonInheritedProperties)
+}
+
+func IsInheritedProperty(tag string) bool {
+	_, ok := inheritedProperty(tag)
+	return ok
+}
+
+// inheritedProperty returns the description of an inherited property.
+func inheritedProperty(tag string) (string, bool) {
+	description, ok := inheritedProperties[tag]
+	return description, ok
+}
+
+// isProperty reports whether tag is a known property, inherited or not.
+func isProperty(tag string) bool {
+	_, ok1 := inheritedProperties[tag]
+	_, ok2 := nonInheritedProperties[tag]
+	return ok1 || ok2
+}
+
+func copyProperties(properties map[string]string) map[string]string {
+	result := make(map[string]string, len(properties))
+	for k, v := range properties {
+		result[k] = v
+	}
+	return result
+}
+
+// KeyValueStore is where a CoaRepository keeps its data. For a missing key,
+// Get returns either a nil value and a nil error, or an error wrapping
+// ErrKeyNotFound; both mean the same. The repository never stores an empty
+// value, so an empty value also reads as missing, while an empty list is
+// stored as a non-empty encoding and reads as present.
 type KeyValueStore interface {
 	Get([]byte) ([]byte, error)
 	Put([]byte, []byte) error
 }
 
+// KeyValueDeleter may be implemented by a KeyValueStore able to remove keys.
+// Stores that don't implement it have deleted keys overwritten with an
+// empty value.
+type KeyValueDeleter interface {
+	Delete([]byte) error
+}
+
 type CoaRepository struct {
-	store KeyValueStore
+	store       KeyValueStore
+	Clock       Clock
+	IDGenerator IDGenerator
+	// InheritParentProperties makes SaveAccount copy the parent's inherited
+	// properties onto a new child that omits them, instead of rejecting it.
+	InheritParentProperties bool
+	// UniqueChartNames makes SaveChartOfAccounts reject a name already used by
+	// another chart. Names are compared globally, regardless of User.
+	UniqueChartNames bool
+	// ExplicitTags stops SaveAccount from tagging new accounts as detail,
+	// so importers can create summary accounts before their children.
+	ExplicitTags bool
+	// AutoManageDetailSummary makes SaveAccount tag new accounts as detail
+	// and turn a detail parent into a summary account when a child is added
+	// to it, which costs an extra write of the parent. Turn it off to
+	// maintain those tags yourself; nothing then keeps them consistent with
+	// the tree. The constructors set it.
+	AutoManageDetailSummary bool
+	// CanConvertToSummary, when set, is consulted before a detail parent is
+	// turned into a summary account because a child was added to it.
+	CanConvertToSummary func(coaid, id string) (bool, error)
+	// Separator splits account numbers into segments, so that "1.1" is a
+	// valid child of "1" but "11" is not. Empty means plain prefix matching.
+	Separator string
+	// NumberSeparators lists the characters that may separate segments of
+	// account numbers, such as ".-/". When set, numbers can't contain any of
+	// them but Separator, so that a chart doesn't mix "1.2" and "1-2".
+	NumberSeparators string
+	// KeyPrefix is prepended to every key, so that independent datasets can
+	// share one KeyValueStore.
+	KeyPrefix string
+	// PerAccount selects the storage layout for accounts. When false, all
+	// accounts of a chart are stored as one list under "accounts/<coaid>".
+	// When true, each account is stored under "accounts/<coaid>/<id>" and
+	// their ids are listed under "account-index/<coaid>". Existing charts
+	// are moved between layouts with MigrateToPerAccount and
+	// MigrateToAccountList. In this layout the operations that change many
+	// accounts, such as RebuildNumbers, Repair, RenameTag and
+	// DeleteAccounts, write them one by one, so a failure midway leaves the
+	// accounts written so far changed.
+	PerAccount bool
+	// DeleteAfterMigration makes the migrations delete the keys of the
+	// layout they copied from.
+	DeleteAfterMigration bool
+	// SkipUnchangedAccounts makes SaveAccount return the stored account
+	// without writing it, and without touching AsOf, when the account is
+	// EqualIgnoringTimestamps to it. Leave it unset to always write.
+	SkipUnchangedAccounts bool
+	// SkipUnchangedWrites makes every write read the key first and skip the
+	// store's Put when the stored value is the same apart from AsOf. It pays
+	// off for stores where reads are cheaper than writes. A skipped save of
+	// a chart or account is neither logged nor published, and the stored
+	// AsOf stays that of the last write that changed something.
+	SkipUnchangedWrites bool
+	// Observer, when set, is told about store latency and failed
+	// operations.
+	Observer Observer
+	// Logger, when set, is told about every chart and account written.
+	Logger Logger
+	// ValidationProfile selects how strictly accounts are validated on
+	// save. The zero value is Strict.
+	ValidationProfile ValidationProfile
+	// AutoPrefixNumbers makes SaveAccount prepend the parent's number and
+	// the Separator to the number of a new child that doesn't start with
+	// it, so that a child of "1.1" submitted as "3" becomes "1.1.3".
+	AutoPrefixNumbers bool
+	// NormalBalances, when set, maps tags to the normal balance,
+	// increaseOnDebit or increaseOnCredit, that SaveAccount adds to an
+	// account tagged with them that has none. Accounts whose tags map to
+	// different normal balances get none. DefaultNormalBalances returns a
+	// suggested mapping.
+	NormalBalances map[string]string
+	// Retry, when set, retries store reads and writes that fail with an
+	// error it classifies as transient.
+	Retry *RetryPolicy
+	feed  *changeFeed
+	// RetainedEarningsTag is the tag that makes SaveAccount set the account
+	// as the chart's retained earnings account. The tag itself isn't stored.
+	// Empty disables it.
+	RetainedEarningsTag string
+	// MaxChartNameLength, MaxAccountNameLength and MaxAccountNumberLength
+	// limit the number of characters of those fields; zero means no limit.
+	// The constructors set generous defaults.
+	MaxChartNameLength     int
+	MaxAccountNameLength   int
+	MaxAccountNumberLength int
+	// ChartNameDisallowedChars lists characters chart names can't contain.
+	ChartNameDisallowedChars string
+	// DisallowedRune, when set, rejects chart names and account names and
+	// numbers containing a character it returns true for. The constructors
+	// set it to NonPrintable.
+	DisallowedRune func(rune) bool
+	// MaxAccountsPerChart makes SaveAccount refuse to create accounts in a
+	// chart that already has that many, removed ones included. Zero means
+	// no limit.
+	MaxAccountsPerChart int
+	// Codec encodes stored values. Nil means MessagePack.
+	Codec Codec
+	// ScopeByUser makes accounts belong to their User: an account's parent
+	// must have the same User.
+	ScopeByUser bool
+	// PreserveTimestamps makes SaveAccount keep a non-zero Created and AsOf
+	// of new accounts instead of setting them to now, so that imports keep
+	// the original dates. A Created in the future is rejected.
+	PreserveTimestamps bool
+	// CollapseWhitespace makes saves replace runs of whitespace inside names
+	// and numbers with a single space. Leading and trailing whitespace is
+	// always trimmed.
+	CollapseWhitespace bool
 }
 
 func NewCoaRepository(store KeyValueStore) *CoaRepository {
-	return &CoaRepository{store}
+	return NewCoaRepositoryWithClock(store, wallClock{})
+}
+
+func NewCoaRepositoryWithPrefix(store KeyValueStore, prefix string) *CoaRepository {
+	r := NewCoaRepository(store)
+	r.KeyPrefix = prefix
+	return r
+}
+
+func NewCoaRepositoryPerAccount(store KeyValueStore) *CoaRepository {
+	r := NewCoaRepository(store)
+	r.PerAccount = true
+	return r
+}
+
+func NewCoaRepositoryWithClock(store KeyValueStore, clock Clock) *CoaRepository {
+	return &CoaRepository{
+		store:                   store,
+		Clock:                   clock,
+		IDGenerator:             uuidGenerator{},
+		Separator:               ".",
+		AutoManageDetailSummary: true,
+		RetainedEarningsTag:     "retainedEarnings",
+		MaxChartNameLength:      DefaultMaxChartNameLength,
+		MaxAccountNameLength:    DefaultMaxAccountNameLength,
+		MaxAccountNumberLength:  DefaultMaxAccountNumberLength,
+		DisallowedRune:          NonPrintable,
+		feed:                    &changeFeed{},
+	}
 }
 
+// AllChartsOfAccounts returns the charts sorted by name and then by id.
+// They are owned by the caller: each call decodes new values, so changing
+// them has no effect until they are saved.
 func (r *CoaRepository) AllChartsOfAccounts() (ChartsOfAccounts, error) {
 	var result ChartsOfAccounts
 	err := r.get("charts-of-accounts", &result)
 	if err != nil {
 		return nil, err
 	}
+	result.sortByName()
+	return result, nil
+}
+
+func (r *CoaRepository) ChartsForUser(user string) (ChartsOfAccounts, error) {
+	coas, err := r.AllChartsOfAccounts()
+	if err != nil {
+		return nil, err
+	}
+	result := ChartsOfAccounts{}
+	for _, coa := range coas {
+		if coa.User == user {
+			result = append(result, coa)
+		}
+	}
 	return result, nil
 }
 
@@ -92,52 +305,200 @@ func (r *CoaRepository) GetChartOfAccounts(coaid string) (*ChartOfAccounts, erro
 	return nil, nil
 }
 
+// GetChartsOfAccounts returns the charts with the given ids in the same
+// order, reading the charts once. Unknown ids are skipped.
+func (r *CoaRepository) GetChartsOfAccounts(ids []string) (ChartsOfAccounts, error) {
+	coas, err := r.AllChartsOfAccounts()
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*ChartOfAccounts, len(coas))
+	for _, coa := range coas {
+		byId[coa.Id] = coa
+	}
+	result := ChartsOfAccounts{}
+	for _, id := range ids {
+		if coa, ok := byId[id]; ok {
+			result = append(result, coa)
+		}
+	}
+	return result, nil
+}
+
+func (r *CoaRepository) GetChartByName(name string) (*ChartOfAccounts, error) {
+	coas, err := r.AllChartsOfAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, coa := range coas {
+		if coa.Name == name {
+			return coa, nil
+		}
+	}
+	return nil, nil
+}
+
 func (r *CoaRepository) SaveChartOfAccounts(coa *ChartOfAccounts) (*ChartOfAccounts, error) {
-	if coa == nil {
-		return nil, fmt.Errorf("Invalid argument: coa is nil")
+	return r.SaveChartOfAccountsAs(coa, "")
+}
+
+func (r *CoaRepository) SaveChartOfAccountsAs(coa *ChartOfAccounts, user string) (*ChartOfAccounts, error) {
+	coa, err := r.saveChartOfAccounts(coa, user, coa != nil && coa.Id == "")
+	return coa, r.observeFailure("SaveChartOfAccounts", err)
+}
+
+// CreateChart is like SaveChartOfAccounts but only inserts. A chart without
+// id gets a generated one; a preset id is kept, but must not be used by
+// another chart.
+func (r *CoaRepository) CreateChart(coa *ChartOfAccounts) (*ChartOfAccounts, error) {
+	coa, err := r.saveChartOfAccounts(coa, "", true)
+	if err != nil {
+		return nil, r.observeFailure("CreateChart", err)
 	}
-	if msg := coa.ValidationMessage(); msg != "" {
-		return nil, fmt.Errorf(msg)
+	return coa, nil
+}
+
+// UpdateChart is like SaveChartOfAccounts but only updates: the chart must
+// already exist, otherwise the error wraps ErrNotFound.
+func (r *CoaRepository) UpdateChart(coa *ChartOfAccounts) (*ChartOfAccounts, error) {
+	if coa != nil && coa.Id == "" {
+		return nil, r.observeFailure("UpdateChart", fmt.Errorf("Invalid argument: chart of accounts id is empty"))
+	}
+	coa, err := r.saveChartOfAccounts(coa, "", false)
+	if err != nil {
+		return nil, r.observeFailure("UpdateChart", err)
+	}
+	return coa, nil
+}
+
+// saveChartOfAccounts inserts the chart when created is set and updates it
+// otherwise.
+func (r *CoaRepository) saveChartOfAccounts(coa *ChartOfAccounts, user string, created bool) (*ChartOfAccounts, error) {
+	if coa != nil {
+		coa.Name = r.normalizeSpace(coa.Name)
 	}
 	coas, err := r.AllChartsOfAccounts()
 	if err != nil {
 		return nil, err
 	}
-	coa.AsOf = time.Now()
-	if coa.Id == "" {
-		coa.Id = uuid.NewV4().String()
-		coa.Created = time.Now()
+	if err := r.validateChartOfAccounts(coa, coas); err != nil {
+		return nil, err
+	}
+	if user == "" {
+		user = coa.User
+	}
+	coa.ModifiedBy = user
+	coa.AsOf = r.Clock.Now()
+	if created {
+		if coa.Id == "" {
+			coa.Id, err = r.newID()
+			if err != nil {
+				return nil, err
+			}
+		}
+		for _, eachcoa := range coas {
+			if eachcoa.Id == coa.Id {
+				return nil, fmt.Errorf("Invalid argument: chart of accounts id %v is already in use", coa.Id)
+			}
+		}
+		coa.Created = r.Clock.Now()
 		coas = append(coas, coa)
 	} else {
+		found := false
 		for i, eachcoa := range coas {
 			if eachcoa.Id == coa.Id {
+				coa.Created = eachcoa.Created
 				coas[i] = coa
+				found = true
 				break
 			}
 		}
+		if !found {
+			return nil, fmt.Errorf("Chart of accounts %v %w", coa.Id, ErrNotFound)
+		}
 	}
-	sort.Slice(coas, func(i, j int) bool { return strings.Compare(coas[i].Name, coas[j].Name) < 0 })
-	err = r.put("charts-of-accounts", coas)
+	written, err := r.putChanged("charts-of-accounts", coas)
 	if err != nil {
 		return nil, err
 	}
+	if written {
+		r.logChart(coa, created)
+		r.publishChart(coa, saveOperation(created))
+	}
 	return coa, nil
 }
 
+func (r *CoaRepository) ValidateChartOfAccounts(coa *ChartOfAccounts) error {
+	coas, err := r.AllChartsOfAccounts()
+	if err != nil {
+		return r.observeFailure("ValidateChartOfAccounts", err)
+	}
+	return r.observeFailure("ValidateChartOfAccounts", r.validateChartOfAccounts(coa, coas))
+}
+
+func (r *CoaRepository) validateChartOfAccounts(coa *ChartOfAccounts, coas ChartsOfAccounts) error {
+	if coa == nil {
+		return fmt.Errorf("Invalid argument: coa is nil")
+	}
+	if msg := coa.ValidationMessage(); msg != "" {
+		return ValidationError(msg)
+	}
+	if msg := r.chartLimitsMessage(coa); msg != "" {
+		return ValidationError(msg)
+	}
+	if r.UniqueChartNames {
+		for _, eachcoa := range coas {
+			if eachcoa.Name == coa.Name && eachcoa.Id != coa.Id {
+				return ValidationError("A chart of accounts with this name already exists")
+			}
+		}
+	}
+	return nil
+}
+
 func (r *CoaRepository) AllAccounts(coaid string) (Accounts, error) {
 	if coaid == "" {
 		return nil, fmt.Errorf("Invalid argument: coaid is empty")
 	}
-	var result Accounts
-	err := r.get("accounts/"+coaid, &result)
+	result, err := r.loadAccounts(coaid)
 	if err != nil {
 		return nil, err
 	}
-	sort.Slice(result, func(i, j int) bool { return strings.Compare(result[i].Number, result[j].Number) < 0 })
+	result.sortByNumber(r.Separator)
 	return result, nil
 }
 
+func (r *CoaRepository) CountAccounts(coaid string) (int, error) {
+	if coaid == "" {
+		return 0, fmt.Errorf("Invalid argument: coaid is empty")
+	}
+	key := "accounts/" + coaid
+	if r.PerAccount {
+		key = accountIndexKey(coaid)
+	}
+	data, err := r.getRaw(key)
+	if err != nil {
+		return 0, err
+	}
+	if len(data) == 0 {
+		return 0, nil
+	}
+	if _, ok := r.codec().(msgpCodec); ok {
+		if n, _, err := msgp.ReadArrayHeaderBytes(data); err == nil {
+			return int(n), nil
+		}
+	}
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return 0, err
+	}
+	return len(aa), nil
+}
+
 func (r *CoaRepository) GetAccount(coaid string, id string) (*Account, error) {
+	if r.PerAccount {
+		return r.loadAccount(coaid, id)
+	}
 	aa, err := r.AllAccounts(coaid)
 	if err != nil {
 		return nil, err
@@ -151,49 +512,278 @@ func (r *CoaRepository) GetAccount(coaid string, id string) (*Account, error) {
 }
 
 func (r *CoaRepository) SaveAccount(coaid string, account *Account) (*Account, error) {
+	return r.SaveAccountAs(coaid, account, "")
+}
+
+func (r *CoaRepository) ValidateAccount(coaid string, account *Account) error {
+	if account != nil {
+		a := *account
+		a.Tags = append(Tags(nil), account.Tags...)
+		account = &a
+	}
+	_, err := r.prepareAccount(coaid, account)
+	return r.observeFailure("ValidateAccount", err)
+}
+
+// normalizeSpace trims s and, when CollapseWhitespace is set, replaces the
+// runs of whitespace inside it with a single space.
+func (r *CoaRepository) normalizeSpace(s string) string {
+	if r.CollapseWhitespace {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	return strings.TrimSpace(s)
+}
+
+func (r *CoaRepository) prepareAccount(coaid string, account *Account) (*preparedAccount, error) {
 	if coaid == "" {
 		return nil, fmt.Errorf("Invalid argument: coaid is empty")
 	}
 	if account == nil {
 		return nil, fmt.Errorf("Invalid argument: account is nil")
 	}
-	var tags []string
-	var retainedEarningsAccount bool
-	for _, k := range account.Tags {
-		if k == "retainedEarnings" {
-			retainedEarningsAccount = true
+	account.Number = r.normalizeSpace(account.Number)
+	account.Name = r.normalizeSpace(account.Name)
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	if coa == nil {
+		return nil, fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	if account.Id == "" {
+		account.Id, err = r.existingDerivedID(coaid, account.Number)
+		if err != nil {
+			return nil, err
 		}
-		_, ok1 := inheritedProperties[k]
-		_, ok2 := nonInheritedProperties[k]
-		if ok1 || ok2 {
+	}
+	var old *Account
+	if account.Id != "" {
+		old, err = r.GetAccount(coaid, account.Id)
+		if err != nil {
+			return nil, err
+		}
+		if old == nil {
+			return nil, fmt.Errorf("Account %v %w", account.Id, ErrNotFound)
+		}
+		if !old.Removed.IsZero() {
+			return nil, fmt.Errorf("Account %v %w", account.Id, ErrRemoved)
+		}
+	}
+	var tags Tags
+	prepared := &preparedAccount{coa: coa, old: old}
+	for _, k := range account.Tags {
+		if k != "" && k == r.RetainedEarningsTag {
+			prepared.retainedEarningsAccount = true
+		} else if isProperty(k) {
 			tags = append(tags, k)
+		} else {
+			prepared.ignoredTags = append(prepared.ignoredTags, k)
 		}
 	}
-	if !account.Tags.Contains("detail") && account.Id == "" {
+	if !account.Tags.Contains("detail") && account.Id == "" && !r.ExplicitTags && r.AutoManageDetailSummary {
 		tags = append(tags, "detail")
 	}
+	if r.InheritParentProperties && account.Id == "" && account.Parent != "" {
+		parent, err := r.GetAccount(coaid, account.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if parent != nil {
+			for _, k := range parent.Tags {
+				if IsInheritedProperty(k) && !tags.Contains(k) {
+					tags = append(tags, k)
+				}
+			}
+		}
+	}
+	if r.NormalBalances != nil && !tags.Contains("increaseOnDebit") && !tags.Contains("increaseOnCredit") {
+		if nb := defaultNormalBalance(tags, r.NormalBalances); nb != "" {
+			tags = append(tags, nb)
+		}
+	}
 	account.Tags = tags
-	account.AsOf = time.Now()
-	if account.Id != "" {
-		old, err := r.GetAccount(coaid, account.Id)
+	if r.AutoPrefixNumbers && account.Id == "" && account.Parent != "" && strings.TrimSpace(account.Number) != "" {
+		parent, err := r.GetAccount(coaid, account.Parent)
 		if err != nil {
 			return nil, err
 		}
+		if parent != nil && !hasNumberPrefix(account.Number, parent.Number, r.Separator) {
+			account.Number = parent.Number + r.Separator + account.Number
+		}
+	}
+	if account.User == "" {
+		account.User = coa.User
+	}
+	if old != nil {
 		account.Number = old.Number
 		account.Parent = old.Parent
 		account.Created = old.Created
 	}
-	if msg := account.ValidationMessage(coaid, r); msg != "" {
-		return nil, fmt.Errorf(msg)
+	if old == nil && r.PreserveTimestamps && account.Created.After(r.Clock.Now()) {
+		return nil, ValidationError("The creation date must not be in the future")
 	}
-	var accounts Accounts
-	err := r.get("accounts/"+coaid, &accounts)
+	msg, warnings := account.validation(coaid, r)
+	if msg != "" {
+		if len(prepared.ignoredTags) > 0 {
+			msg += " (ignored unknown tags: " + strings.Join(prepared.ignoredTags, ", ") + ")"
+		}
+		return nil, ValidationError(msg)
+	}
+	prepared.warnings = warnings
+	if account.Parent != "" && r.CanConvertToSummary != nil && r.AutoManageDetailSummary {
+		parent, err := r.GetAccount(coaid, account.Parent)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Tags.Contains("detail") || !parent.Tags.Contains("summary") {
+			ok, err := r.CanConvertToSummary(coaid, parent.Id)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, ValidationError("Parent has postings and cannot become a summary account")
+			}
+		}
+	}
+	return prepared, nil
+}
+
+func (r *CoaRepository) SaveAccountAs(coaid string, account *Account, user string) (*Account, error) {
+	result, err := r.saveAccount(coaid, account, user)
+	if err != nil {
+		return nil, r.observeFailure("SaveAccount", err)
+	}
+	return result.Account, nil
+}
+
+func (r *CoaRepository) SaveAccountWithResult(coaid string, account *Account) (*SaveAccountResult, error) {
+	result, err := r.saveAccount(coaid, account, "")
+	return result, r.observeFailure("SaveAccount", err)
+}
+
+// CreateAccount is like SaveAccount but only inserts: the account must not
+// have an id yet, nor, with an AccountIDGenerator, a number whose derived id
+// is already in use.
+func (r *CoaRepository) CreateAccount(coaid string, account *Account) (*Account, error) {
+	if account != nil && account.Id != "" {
+		return nil, r.observeFailure("CreateAccount", fmt.Errorf("Invalid argument: account already has id %v", account.Id))
+	}
+	if account != nil {
+		id, err := r.existingDerivedID(coaid, r.normalizeSpace(account.Number))
+		if err != nil {
+			return nil, r.observeFailure("CreateAccount", err)
+		}
+		if id != "" {
+			return nil, r.observeFailure("CreateAccount", fmt.Errorf("Invalid argument: id %v of number %v is already in use", id, account.Number))
+		}
+	}
+	result, err := r.saveAccount(coaid, account, "")
+	if err != nil {
+		return nil, r.observeFailure("CreateAccount", err)
+	}
+	return result.Account, nil
+}
+
+// UpdateAccount is like SaveAccount but only updates: the account must
+// already exist, otherwise the error wraps ErrNotFound.
+func (r *CoaRepository) UpdateAccount(coaid string, account *Account) (*Account, error) {
+	if account != nil && account.Id == "" {
+		return nil, r.observeFailure("UpdateAccount", fmt.Errorf("Invalid argument: account id is empty"))
+	}
+	result, err := r.saveAccount(coaid, account, "")
+	if err != nil {
+		return nil, r.observeFailure("UpdateAccount", err)
+	}
+	return result.Account, nil
+}
+
+// existingDerivedID returns the id the AccountIDGenerator derives from
+// number when an account with that id and number exists, or "" otherwise.
+func (r *CoaRepository) existingDerivedID(coaid, number string) (string, error) {
+	g, ok := r.IDGenerator.(AccountIDGenerator)
+	if !ok {
+		return "", nil
+	}
+	id, err := g.AccountID(number)
+	if err != nil {
+		return "", fmt.Errorf("Unable to generate id: %w", err)
+	}
+	existing, err := r.GetAccount(coaid, id)
+	if err != nil {
+		return "", err
+	}
+	if existing == nil || existing.Number != number {
+		return "", nil
+	}
+	return id, nil
+}
+
+func (r *CoaRepository) saveAccount(coaid string, account *Account, user string) (*SaveAccountResult, error) {
+	prepared, err := r.prepareAccount(coaid, account)
 	if err != nil {
 		return nil, err
 	}
 	if account.Id == "" {
-		account.Id = uuid.NewV4().String()
-		account.Created = time.Now()
+		if err := r.checkAccountLimit(coaid, 1); err != nil {
+			return nil, err
+		}
+	}
+	// In the per-account layout only the account and the index are
+	// written, so the other accounts aren't loaded.
+	var accounts Accounts
+	if !r.PerAccount {
+		accounts, err = r.loadAccounts(coaid)
+		if err != nil {
+			return nil, err
+		}
+	}
+	created := account.Id == ""
+	pointsToAccount := !prepared.retainedEarningsAccount || prepared.coa.RetainedEarningsAccount == account.Id
+	if old := prepared.old; old != nil && r.SkipUnchangedAccounts && pointsToAccount && old.EqualIgnoringTimestamps(account) {
+		return &SaveAccountResult{Account: old}, nil
+	}
+	var parent *Account
+	if account.Parent != "" && r.AutoManageDetailSummary {
+		parent, err = r.summaryParent(coaid, account.Parent)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if parent != nil {
+		// The parent is validated before the child is written, so that a
+		// parent that can't be saved doesn't leave an orphaned child behind.
+		if _, err := r.prepareAccount(coaid, parent.Clone()); err != nil {
+			return nil, err
+		}
+	}
+	result := &SaveAccountResult{Account: account, Created: created, Warnings: prepared.warnings, IgnoredTags: prepared.ignoredTags}
+	modifiedBy := user
+	if modifiedBy == "" {
+		modifiedBy = account.User
+	}
+	account.ModifiedBy = modifiedBy
+	preserve := created && r.PreserveTimestamps
+	if !preserve || account.AsOf.IsZero() {
+		account.AsOf = r.Clock.Now()
+	}
+	if created {
+		id, derived, err := r.newAccountID(account.Number)
+		if err != nil {
+			return nil, err
+		}
+		if derived {
+			existing, err := r.GetAccount(coaid, id)
+			if err != nil {
+				return nil, err
+			}
+			if existing != nil {
+				return nil, fmt.Errorf("Invalid argument: id %v of number %v is already in use", id, account.Number)
+			}
+		}
+		account.Id = id
+		if !preserve || account.Created.IsZero() {
+			account.Created = r.Clock.Now()
+		}
 		accounts = append(accounts, account)
 	} else {
 		for i, a := range accounts {
@@ -203,69 +793,71 @@ func (r *CoaRepository) SaveAccount(coaid string, account *Account) (*Account, e
 			}
 		}
 	}
-	err = r.put("accounts/"+coaid, accounts)
+	written, err := r.storeChangedAccount(coaid, accounts, account, created)
 	if err != nil {
 		return nil, err
 	}
-	if retainedEarningsAccount {
-		coa, err := r.GetChartOfAccounts(coaid)
-		if err != nil {
-			return nil, err
-		}
+	if written {
+		r.logAccount(coaid, account, created)
+		r.publishAccounts(coaid, saveOperation(created), account)
+	}
+	if coa := prepared.coa; prepared.retainedEarningsAccount && coa.RetainedEarningsAccount != account.Id {
 		coa.RetainedEarningsAccount = account.Id
-		_, err = r.SaveChartOfAccounts(coa)
+		result.ChangedChart, err = r.SaveChartOfAccountsAs(coa, user)
 		if err != nil {
 			return nil, err
 		}
 	}
-	if account.Parent != "" {
-		parent, err := r.GetAccount(coaid, account.Parent)
+	if parent != nil {
+		_, err := r.saveAccount(coaid, parent, user)
 		if err != nil {
 			return nil, err
 		}
-		changed := false
-		i := parent.Tags.IndexOf("detail")
-		if i != -1 {
-			parent.Tags = append(parent.Tags[:i], parent.Tags[i+1:]...)
-			changed = true
-		}
-		if !parent.Tags.Contains("summary") {
-			parent.Tags = append(parent.Tags, "summary")
-			changed = true
-		}
-		if changed {
-			_, err := r.SaveAccount(coaid, parent)
-			if err != nil {
-				return nil, err
-			}
-		}
+		result.ChangedParent = parent
 	}
-	return account, nil
+	return result, nil
 }
 
+// summaryParent returns the parent with its tags converted to summary, or
+// nil when it already is a summary account.
+func (r *CoaRepository) summaryParent(coaid, id string) (*Account, error) {
+	parent, err := r.GetAccount(coaid, id)
+	if err != nil {
+		return nil, err
+	}
+	if parent == nil {
+		return nil, fmt.Errorf("Parent not found: %v", id)
+	}
+	changed := false
+	i := parent.Tags.IndexOf("detail")
+	if i != -1 {
+		parent.Tags = append(parent.Tags[:i], parent.Tags[i+1:]...)
+		changed = true
+	}
+	if !parent.Tags.Contains("summary") {
+		parent.Tags = append(parent.Tags, "summary")
+		changed = true
+	}
+	if !changed {
+		return nil, nil
+	}
+	return parent, nil
+}
+
+// Indexes returns the positions of the accounts in the stored list, or -1
+// when an account is missing or lacks the tags. Positions shift whenever an
+// account is added, so they are not stable; prefer ResolveAccounts.
 func (r *CoaRepository) Indexes(coaid string, accountsIds []string, tags []string) ([]int, error) {
 	if coaid == "" {
 		return nil, fmt.Errorf("Invalid argument: coaid is empty")
 	}
-	var accounts Accounts
-	err := r.get("accounts/"+coaid, &accounts)
-	if err != nil {
-		return nil, err
-	}
-	result := make([]int, len(accountsIds))
+	queries := make([]IndexQuery, len(accountsIds))
 	for i, id := range accountsIds {
-		result[i] = -1
-		for j, a := range accounts {
-			if a.Id == id && a.Tags.ContainsAll(tags) {
-				result[i] = j
-			}
-		}
+		queries[i] = IndexQuery{Id: id, Tags: tags}
 	}
-	return result, nil
+	return r.IndexesMulti(coaid, queries)
 }
 
-// TODO: DeleteAccount
-
 func (coa *ChartOfAccounts) ValidationMessage() string {
 	if len(strings.TrimSpace(coa.Name)) == 0 {
 		return "The name must be informed"
@@ -274,86 +866,330 @@ func (coa *ChartOfAccounts) ValidationMessage() string {
 }
 
 func (account *Account) ValidationMessage(coaid string, r *CoaRepository) string {
+	msg, _ := account.validation(coaid, r)
+	return msg
+}
+
+// validation returns the first rule the account breaks and, under the
+// Lenient profile, the downgraded rules it breaks as warnings.
+func (account *Account) validation(coaid string, r *CoaRepository) (string, []string) {
+	lenient := r.ValidationProfile == Lenient
+	msg, warnings := account.localValidation(lenient)
+	if msg != "" {
+		return msg, nil
+	}
+	if msg := r.accountLimitsMessage(account); msg != "" {
+		return msg, nil
+	}
+	if msg := separatorMessage(account.Number, r.Separator, r.NumberSeparators); msg != "" {
+		return msg, nil
+	}
+	// In the list layout every account is decoded on each read, so they are
+	// read once and the parent and ancestors are looked up among them.
+	var accounts Accounts
+	var byId map[string]*Account
+	if !r.PerAccount {
+		var err error
+		accounts, err = r.loadAccounts(coaid)
+		if err != nil {
+			return err.Error(), nil
+		}
+		byId = make(map[string]*Account, len(accounts))
+		for _, a := range accounts {
+			byId[a.Id] = a
+		}
+	}
+	find := func(id string) (*Account, error) {
+		if byId != nil {
+			return byId[id], nil
+		}
+		return r.loadAccount(coaid, id)
+	}
+	inUse, err := r.numberInUse(coaid, account, accounts)
+	if err != nil {
+		return err.Error(), nil
+	}
+	if inUse {
+		return "An account with this number already exists", nil
+	}
+	if account.Parent != "" {
+		parent, err := find(account.Parent)
+		if err != nil {
+			return err.Error(), nil
+		}
+		if parent == nil {
+			return "Parent not found: " + account.Parent, nil
+		}
+		if r.ScopeByUser && parent.User != account.User {
+			return "The parent must belong to the same user", nil
+		}
+		if account.Id != "" {
+			if account.Parent == account.Id {
+				return "An account cannot be its own parent", nil
+			}
+			visited := map[string]bool{}
+			for a := parent; a != nil && !visited[a.Id]; {
+				visited[a.Id] = true
+				if a.Id == account.Id {
+					return "The parent cannot be a descendant of the account", nil
+				}
+				if a.Parent == "" {
+					break
+				}
+				if a, err = find(a.Parent); err != nil {
+					return err.Error(), nil
+				}
+			}
+		}
+		if !hasNumberPrefix(account.Number, parent.Number, r.Separator) {
+			return "The number must start with parent's number", nil
+		}
+		visited := map[string]bool{}
+		for ancestor := parent; !visited[ancestor.Id]; {
+			visited[ancestor.Id] = true
+			for _, key := range CheckInheritance(account, Accounts{ancestor}) {
+				description, _ := inheritedProperty(key)
+				msg := "The " + description + " must be same as the ancestor " + ancestor.Number
+				if ancestor == parent {
+					msg = "The " + description + " must be same as the parent"
+				}
+				if !lenient {
+					return msg, nil
+				}
+				warnings = append(warnings, msg)
+			}
+			if ancestor.Parent == "" {
+				break
+			}
+			next, err := find(ancestor.Parent)
+			if err != nil {
+				return err.Error(), nil
+			}
+			if next == nil {
+				return "Parent not found: " + ancestor.Parent, nil
+			}
+			ancestor = next
+		}
+	}
+	return "", warnings
+}
+
+// localValidationMessage checks the rules that don't depend on other
+// accounts of the chart.
+func (account *Account) localValidationMessage() string {
+	msg, _ := account.localValidation(false)
+	return msg
+}
+
+func (account *Account) localValidation(lenient bool) (string, []string) {
+	var warnings []string
 	if len(strings.TrimSpace(account.Number)) == 0 {
-		return "The number must be informed"
+		return "The number must be informed", nil
 	}
 	if len(strings.TrimSpace(account.Name)) == 0 {
-		return "The name must be informed"
+		return "The name must be informed", nil
 	}
 	if !account.Tags.Contains("balanceSheet") && !account.Tags.Contains("incomeStatement") {
-		return "The financial statement must be informed"
+		return "The financial statement must be informed", nil
 	}
 	if account.Tags.Contains("balanceSheet") && account.Tags.Contains("incomeStatement") {
-		return "The statement must be either balance sheet or income statement"
+		return "The statement must be either balance sheet or income statement", nil
 	}
 	if !account.Tags.Contains("increaseOnDebit") && !account.Tags.Contains("increaseOnCredit") {
-		return "The normal balance must be informed"
+		return "The normal balance must be informed", nil
 	}
 	if account.Tags.Contains("increaseOnDebit") && account.Tags.Contains("increaseOnCredit") {
-		return "The normal balance must be either debit or credit"
+		return "The normal balance must be either debit or credit", nil
 	}
 	count := 0
 	for _, p := range account.Tags {
-		if inheritedProperties[p] == "income statement attribute" {
+		if description, _ := inheritedProperty(p); description == "income statement attribute" {
 			count++
 		}
 	}
 	if count > 1 {
-		return "Only one income statement attribute is allowed"
+		if !lenient {
+			return "Only one income statement attribute is allowed", nil
+		}
+		warnings = append(warnings, "Only one income statement attribute is allowed")
 	}
-	if account.Id == "" {
-		aa, err := r.AllAccounts(coaid)
-		if err != nil {
-			return err.Error()
+	if account.Tags.Contains("detail") && account.Tags.Contains("summary") {
+		return "The account must be either detail or summary", nil
+	}
+	return "", warnings
+}
+
+func (r *CoaRepository) put(key string, v interface{}) error {
+	_, err := r.putChanged(key, v)
+	return err
+}
+
+// putChanged is like put but also reports whether v was written, which it
+// isn't when SkipUnchangedWrites finds the stored value unchanged.
+func (r *CoaRepository) putChanged(key string, v interface{}) (bool, error) {
+	switch v := v.(type) {
+	case Accounts:
+		for _, a := range v {
+			a.Tags = a.Tags.normalize()
+			a.recordZone()
 		}
-		for _, a := range aa {
-			if a.Number == account.Number {
-				return "An account with this number already exists"
-			}
+		v.sortByNumber(r.Separator)
+	case *Account:
+		v.Tags = v.Tags.normalize()
+		v.recordZone()
+	case ChartsOfAccounts:
+		for _, coa := range v {
+			coa.recordZone()
 		}
+		v.sortByName()
 	}
-	if account.Parent != "" {
-		parent, err := r.GetAccount(coaid, account.Parent)
+	// data, err := json.Marshal(v)
+	data, err := r.codec().Marshal(v)
+	if err != nil {
+		return false, err
+	}
+	if r.SkipUnchangedWrites {
+		stored, err := r.getRaw(key)
 		if err != nil {
-			return err.Error()
+			return false, err
 		}
-		if parent == nil {
-			return "Parent not found: " + account.Parent
+		if stored != nil && (bytes.Equal(stored, data) || r.equalIgnoringAsOf(stored, v)) {
+			return false, nil
 		}
-		if !strings.HasPrefix(account.Number, parent.Number) {
-			return "The number must start with parent's number"
+	}
+	return true, r.putRaw(key, data)
+}
+
+// equalIgnoringAsOf reports whether the stored bytes decode to v apart from
+// AsOf, which changes on every write.
+func (r *CoaRepository) equalIgnoringAsOf(stored []byte, v interface{}) bool {
+	var old interface{}
+	switch v.(type) {
+	case Accounts:
+		var accounts Accounts
+		if err := r.codec().Unmarshal(stored, &accounts); err != nil {
+			return false
+		}
+		old = accounts
+	case *Account:
+		account := &Account{}
+		if err := r.codec().Unmarshal(stored, account); err != nil {
+			return false
+		}
+		old = account
+	case ChartsOfAccounts:
+		var coas ChartsOfAccounts
+		if err := r.codec().Unmarshal(stored, &coas); err != nil {
+			return false
 		}
-		for key, value := range inheritedProperties {
-			if parent.Tags.Contains(key) && !account.Tags.Contains(key) {
-				return "The " + value + " must be same as the parent"
+		old = coas
+	default:
+		return false
+	}
+	a, err := r.codec().Marshal(withoutAsOf(old))
+	if err != nil {
+		return false
+	}
+	b, err := r.codec().Marshal(withoutAsOf(v))
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(a, b)
+}
+
+// withoutAsOf returns a copy of v with AsOf, and the zone recorded for it,
+// cleared.
+func withoutAsOf(v interface{}) interface{} {
+	switch v := v.(type) {
+	case Accounts:
+		result := make(Accounts, len(v))
+		for i, a := range v {
+			result[i] = withoutAsOf(a).(*Account)
+		}
+		return result
+	case *Account:
+		clone := v.Clone()
+		clone.AsOf = time.Time{}
+		if len(clone.Zones) > 0 {
+			clone.Zones[0] = TimeZone{}
+		}
+		return clone
+	case ChartsOfAccounts:
+		result := make(ChartsOfAccounts, len(v))
+		for i, coa := range v {
+			clone := coa.Clone()
+			clone.AsOf = time.Time{}
+			if len(clone.Zones) > 0 {
+				clone.Zones[0] = TimeZone{}
 			}
+			result[i] = clone
 		}
+		return result
 	}
-	return ""
+	return v
 }
 
-func (r *CoaRepository) put(key string, v interface{}) error {
-	// data, err := json.Marshal(v)
-	data, err := v.(msgp.Marshaler).MarshalMsg(nil)
-	if err != nil {
+func (r *CoaRepository) putRaw(key string, data []byte) error {
+	start := time.Now()
+	err := r.withRetry(func() error {
+		return r.store.Put([]byte(r.KeyPrefix+key), data)
+	})
+	r.observePut(start, key, err)
+	return err
+}
+
+func (r *CoaRepository) delete(key string) error {
+	return r.withRetry(func() error {
+		if d, ok := r.store.(KeyValueDeleter); ok {
+			return d.Delete([]byte(r.KeyPrefix + key))
+		}
+		return r.store.Put([]byte(r.KeyPrefix+key), nil)
+	})
+}
+
+func (r *CoaRepository) getRaw(key string) ([]byte, error) {
+	start := time.Now()
+	var data []byte
+	err := r.withRetry(func() error {
+		var err error
+		data, err = r.store.Get([]byte(r.KeyPrefix + key))
+		if errors.Is(err, ErrKeyNotFound) {
+			data, err = nil, nil
+		}
 		return err
+	})
+	r.observeGet(start, err)
+	if len(data) == 0 {
+		data = nil
 	}
-	return r.store.Put([]byte(key), data)
+	return data, err
 }
 
 func (r *CoaRepository) get(key string, v interface{}) error {
-	data, err := r.store.Get([]byte(key))
+	data, err := r.getRaw(key)
 	if err != nil {
 		return err
 	}
-	if data == nil || len(data) == 0 {
+	if data == nil {
 		return nil
 	}
-	_, err = v.(msgp.Unmarshaler).UnmarshalMsg(data)
+	err = r.codec().Unmarshal(data, v)
 	// err = json.Unmarshal(data, v)
 	if err != nil {
 		return err
 	}
+	switch v := v.(type) {
+	case *Accounts:
+		for _, a := range *v {
+			a.restoreZone()
+		}
+	case *Account:
+		v.restoreZone()
+	case *ChartsOfAccounts:
+		for _, coa := range *v {
+			coa.restoreZone()
+		}
+	}
 	return nil
 }
 
@@ -362,11 +1198,81 @@ func (aa Accounts) String() string {
 	for i, a := range aa {
 		ss[i] = a.String()
 	}
-	return strings.Join(ss, ", ")
+	return strings.Join(ss, "\n")
+}
+
+func (coas ChartsOfAccounts) sortByName() {
+	sort.SliceStable(coas, func(i, j int) bool {
+		if c := strings.Compare(coas[i].Name, coas[j].Name); c != 0 {
+			return c < 0
+		}
+		return coas[i].Id < coas[j].Id
+	})
+}
+
+func (aa Accounts) sortByNumber(sep string) {
+	sort.SliceStable(aa, func(i, j int) bool {
+		if c := compareNumbers(aa[i].Number, aa[j].Number, sep); c != 0 {
+			return c < 0
+		}
+		return aa[i].Id < aa[j].Id
+	})
 }
 
+// String formats the account as "<number> <name> [tags]", with the tags
+// sorted.
 func (a *Account) String() string {
-	return fmt.Sprint(*a)
+	if a == nil {
+		return "<nil>"
+	}
+	tags := append(Tags(nil), a.Tags...).normalize()
+	return a.Number + " " + a.Name + " [" + strings.Join(tags, " ") + "]"
+}
+
+func (a *Account) IsDetail() bool { return a.Tags.Contains("detail") }
+
+func (a *Account) IsSummary() bool { return a.Tags.Contains("summary") }
+
+// Postable tells whether entries can be posted to the account, which is only
+// the case for detail accounts. Saving and Validate reject accounts tagged
+// both detail and summary.
+func (a *Account) Postable() bool { return a.IsDetail() && !a.IsSummary() }
+
+// Clone returns a deep copy of the account.
+func (a *Account) Clone() *Account {
+	if a == nil {
+		return nil
+	}
+	clone := *a
+	clone.Tags = append(Tags(nil), a.Tags...)
+	clone.Zones = append([]TimeZone(nil), a.Zones...)
+	return &clone
+}
+
+// Clone returns a copy of the chart of accounts.
+func (coa *ChartOfAccounts) Clone() *ChartOfAccounts {
+	if coa == nil {
+		return nil
+	}
+	clone := *coa
+	clone.Zones = append([]TimeZone(nil), coa.Zones...)
+	return &clone
+}
+
+// EqualIgnoringTimestamps reports whether both accounts have the same number,
+// name, tags, parent, user and removal state. AsOf, Created, ModifiedBy and
+// the exact removal time are not compared.
+func (a *Account) EqualIgnoringTimestamps(other *Account) bool {
+	if a == nil || other == nil {
+		return a == other
+	}
+	return a.Id == other.Id &&
+		a.Number == other.Number &&
+		a.Name == other.Name &&
+		a.Tags.Equal(other.Tags) &&
+		a.Parent == other.Parent &&
+		a.User == other.User &&
+		a.Removed.IsZero() == other.Removed.IsZero()
 }
 
 func (c Tags) IndexOf(s string) int {
@@ -380,6 +1286,18 @@ func (c Tags) IndexOf(s string) int {
 
 func (c Tags) Contains(s string) bool { return c.IndexOf(s) != -1 }
 
+// normalize sorts the tags and removes duplicates in place.
+func (c Tags) normalize() Tags {
+	sort.Strings(c)
+	result := c[:0]
+	for i, s := range c {
+		if i == 0 || s != c[i-1] {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
 func (c Tags) ContainsAll(ss []string) bool {
 	for _, s := range ss {
 		if !c.Contains(s) {
@@ -388,3 +1306,37 @@ func (c Tags) ContainsAll(ss []string) bool {
 	}
 	return true
 }
+
+// ContainsAny tells whether any of ss is in c; it is false when ss is empty.
+func (c Tags) ContainsAny(ss []string) bool {
+	for _, s := range ss {
+		if c.Contains(s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c Tags) Equal(other Tags) bool {
+	return c.ContainsAll(other) && other.ContainsAll(c)
+}
+
+func (c Tags) Intersect(other Tags) Tags {
+	var result Tags
+	for _, s := range c {
+		if other.Contains(s) && !result.Contains(s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+func (c Tags) Without(other Tags) Tags {
+	var result Tags
+	for _, s := range c {
+		if !other.Contains(s) && !result.Contains(s) {
+			result = append(result, s)
+		}
+	}
+	return result
+}
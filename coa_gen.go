@@ -66,6 +66,11 @@ func (z *Account) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "ModifiedBy":
+			z.ModifiedBy, err = dc.ReadString()
+			if err != nil {
+				return
+			}
 		case "AsOf":
 			z.AsOf, err = dc.ReadTime()
 			if err != nil {
@@ -81,6 +86,53 @@ func (z *Account) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "Reactivated":
+			z.Reactivated, err = dc.ReadTime()
+			if err != nil {
+				return
+			}
+		case "Zones":
+			var zb0003 uint32
+			zb0003, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Zones) >= int(zb0003) {
+				z.Zones = (z.Zones)[:zb0003]
+			} else {
+				z.Zones = make([]TimeZone, zb0003)
+			}
+			for za0002 := range z.Zones {
+				var zb0004 uint32
+				zb0004, err = dc.ReadMapHeader()
+				if err != nil {
+					return
+				}
+				for zb0004 > 0 {
+					zb0004--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Name":
+						z.Zones[za0002].Name, err = dc.ReadString()
+						if err != nil {
+							return
+						}
+					case "Offset":
+						z.Zones[za0002].Offset, err = dc.ReadInt()
+						if err != nil {
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -93,9 +145,9 @@ func (z *Account) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *Account) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 9
+	// map header, size 12
 	// write "Id"
-	err = en.Append(0x89, 0xa2, 0x49, 0x64)
+	err = en.Append(0x8c, 0xa2, 0x49, 0x64)
 	if err != nil {
 		return err
 	}
@@ -154,6 +206,15 @@ func (z *Account) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "ModifiedBy"
+	err = en.Append(0xaa, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x42, 0x79)
+	if err != nil {
+		return err
+	}
+	err = en.WriteString(z.ModifiedBy)
+	if err != nil {
+		return
+	}
 	// write "AsOf"
 	err = en.Append(0xa4, 0x41, 0x73, 0x4f, 0x66)
 	if err != nil {
@@ -181,15 +242,54 @@ func (z *Account) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "Reactivated"
+	err = en.Append(0xab, 0x52, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x64)
+	if err != nil {
+		return err
+	}
+	err = en.WriteTime(z.Reactivated)
+	if err != nil {
+		return
+	}
+	// write "Zones"
+	err = en.Append(0xa5, 0x5a, 0x6f, 0x6e, 0x65, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Zones)))
+	if err != nil {
+		return
+	}
+	for za0002 := range z.Zones {
+		// map header, size 2
+		// write "Name"
+		err = en.Append(0x82, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
+		if err != nil {
+			return
+		}
+		err = en.WriteString(z.Zones[za0002].Name)
+		if err != nil {
+			return
+		}
+		// write "Offset"
+		err = en.Append(0xa6, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt(z.Zones[za0002].Offset)
+		if err != nil {
+			return
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *Account) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 9
+	// map header, size 12
 	// string "Id"
-	o = append(o, 0x89, 0xa2, 0x49, 0x64)
+	o = append(o, 0x8c, 0xa2, 0x49, 0x64)
 	o = msgp.AppendString(o, z.Id)
 	// string "Number"
 	o = append(o, 0xa6, 0x4e, 0x75, 0x6d, 0x62, 0x65, 0x72)
@@ -209,6 +309,9 @@ func (z *Account) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "User"
 	o = append(o, 0xa4, 0x55, 0x73, 0x65, 0x72)
 	o = msgp.AppendString(o, z.User)
+	// string "ModifiedBy"
+	o = append(o, 0xaa, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x42, 0x79)
+	o = msgp.AppendString(o, z.ModifiedBy)
 	// string "AsOf"
 	o = append(o, 0xa4, 0x41, 0x73, 0x4f, 0x66)
 	o = msgp.AppendTime(o, z.AsOf)
@@ -218,6 +321,21 @@ func (z *Account) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "Removed"
 	o = append(o, 0xa7, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64)
 	o = msgp.AppendTime(o, z.Removed)
+	// string "Reactivated"
+	o = append(o, 0xab, 0x52, 0x65, 0x61, 0x63, 0x74, 0x69, 0x76, 0x61, 0x74, 0x65, 0x64)
+	o = msgp.AppendTime(o, z.Reactivated)
+	// string "Zones"
+	o = append(o, 0xa5, 0x5a, 0x6f, 0x6e, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Zones)))
+	for za0002 := range z.Zones {
+		// map header, size 2
+		// string "Name"
+		o = append(o, 0x82, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
+		o = msgp.AppendString(o, z.Zones[za0002].Name)
+		// string "Offset"
+		o = append(o, 0xa6, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74)
+		o = msgp.AppendInt(o, z.Zones[za0002].Offset)
+	}
 	return
 }
 
@@ -279,6 +397,11 @@ func (z *Account) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "ModifiedBy":
+			z.ModifiedBy, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				return
+			}
 		case "AsOf":
 			z.AsOf, bts, err = msgp.ReadTimeBytes(bts)
 			if err != nil {
@@ -294,6 +417,53 @@ func (z *Account) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "Reactivated":
+			z.Reactivated, bts, err = msgp.ReadTimeBytes(bts)
+			if err != nil {
+				return
+			}
+		case "Zones":
+			var zb0003 uint32
+			zb0003, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Zones) >= int(zb0003) {
+				z.Zones = (z.Zones)[:zb0003]
+			} else {
+				z.Zones = make([]TimeZone, zb0003)
+			}
+			for za0002 := range z.Zones {
+				var zb0004 uint32
+				zb0004, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					return
+				}
+				for zb0004 > 0 {
+					zb0004--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Name":
+						z.Zones[za0002].Name, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							return
+						}
+					case "Offset":
+						z.Zones[za0002].Offset, bts, err = msgp.ReadIntBytes(bts)
+						if err != nil {
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -311,7 +481,10 @@ func (z *Account) Msgsize() (s int) {
 	for za0001 := range z.Tags {
 		s += msgp.StringPrefixSize + len(z.Tags[za0001])
 	}
-	s += 7 + msgp.StringPrefixSize + len(z.Parent) + 5 + msgp.StringPrefixSize + len(z.User) + 5 + msgp.TimeSize + 8 + msgp.TimeSize + 8 + msgp.TimeSize
+	s += 7 + msgp.StringPrefixSize + len(z.Parent) + 5 + msgp.StringPrefixSize + len(z.User) + 11 + msgp.StringPrefixSize + len(z.ModifiedBy) + 5 + msgp.TimeSize + 8 + msgp.TimeSize + 8 + msgp.TimeSize + 12 + msgp.TimeSize + 6 + msgp.ArrayHeaderSize
+	for za0002 := range z.Zones {
+		s += 1 + 5 + msgp.StringPrefixSize + len(z.Zones[za0002].Name) + 7 + msgp.IntSize
+	}
 	return
 }
 
@@ -468,6 +641,11 @@ func (z *ChartOfAccounts) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "ModifiedBy":
+			z.ModifiedBy, err = dc.ReadString()
+			if err != nil {
+				return
+			}
 		case "AsOf":
 			z.AsOf, err = dc.ReadTime()
 			if err != nil {
@@ -483,6 +661,48 @@ func (z *ChartOfAccounts) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "Zones":
+			var zb0002 uint32
+			zb0002, err = dc.ReadArrayHeader()
+			if err != nil {
+				return
+			}
+			if cap(z.Zones) >= int(zb0002) {
+				z.Zones = (z.Zones)[:zb0002]
+			} else {
+				z.Zones = make([]TimeZone, zb0002)
+			}
+			for za0001 := range z.Zones {
+				var zb0003 uint32
+				zb0003, err = dc.ReadMapHeader()
+				if err != nil {
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, err = dc.ReadMapKeyPtr()
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Name":
+						z.Zones[za0001].Name, err = dc.ReadString()
+						if err != nil {
+							return
+						}
+					case "Offset":
+						z.Zones[za0001].Offset, err = dc.ReadInt()
+						if err != nil {
+							return
+						}
+					default:
+						err = dc.Skip()
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -495,9 +715,9 @@ func (z *ChartOfAccounts) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *ChartOfAccounts) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 7
+	// map header, size 9
 	// write "Id"
-	err = en.Append(0x87, 0xa2, 0x49, 0x64)
+	err = en.Append(0x89, 0xa2, 0x49, 0x64)
 	if err != nil {
 		return err
 	}
@@ -532,6 +752,15 @@ func (z *ChartOfAccounts) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "ModifiedBy"
+	err = en.Append(0xaa, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x42, 0x79)
+	if err != nil {
+		return err
+	}
+	err = en.WriteString(z.ModifiedBy)
+	if err != nil {
+		return
+	}
 	// write "AsOf"
 	err = en.Append(0xa4, 0x41, 0x73, 0x4f, 0x66)
 	if err != nil {
@@ -559,15 +788,45 @@ func (z *ChartOfAccounts) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "Zones"
+	err = en.Append(0xa5, 0x5a, 0x6f, 0x6e, 0x65, 0x73)
+	if err != nil {
+		return err
+	}
+	err = en.WriteArrayHeader(uint32(len(z.Zones)))
+	if err != nil {
+		return
+	}
+	for za0001 := range z.Zones {
+		// map header, size 2
+		// write "Name"
+		err = en.Append(0x82, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
+		if err != nil {
+			return
+		}
+		err = en.WriteString(z.Zones[za0001].Name)
+		if err != nil {
+			return
+		}
+		// write "Offset"
+		err = en.Append(0xa6, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74)
+		if err != nil {
+			return
+		}
+		err = en.WriteInt(z.Zones[za0001].Offset)
+		if err != nil {
+			return
+		}
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *ChartOfAccounts) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 7
+	// map header, size 9
 	// string "Id"
-	o = append(o, 0x87, 0xa2, 0x49, 0x64)
+	o = append(o, 0x89, 0xa2, 0x49, 0x64)
 	o = msgp.AppendString(o, z.Id)
 	// string "Name"
 	o = append(o, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
@@ -578,6 +837,9 @@ func (z *ChartOfAccounts) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "User"
 	o = append(o, 0xa4, 0x55, 0x73, 0x65, 0x72)
 	o = msgp.AppendString(o, z.User)
+	// string "ModifiedBy"
+	o = append(o, 0xaa, 0x4d, 0x6f, 0x64, 0x69, 0x66, 0x69, 0x65, 0x64, 0x42, 0x79)
+	o = msgp.AppendString(o, z.ModifiedBy)
 	// string "AsOf"
 	o = append(o, 0xa4, 0x41, 0x73, 0x4f, 0x66)
 	o = msgp.AppendTime(o, z.AsOf)
@@ -587,6 +849,18 @@ func (z *ChartOfAccounts) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "Removed"
 	o = append(o, 0xa7, 0x52, 0x65, 0x6d, 0x6f, 0x76, 0x65, 0x64)
 	o = msgp.AppendTime(o, z.Removed)
+	// string "Zones"
+	o = append(o, 0xa5, 0x5a, 0x6f, 0x6e, 0x65, 0x73)
+	o = msgp.AppendArrayHeader(o, uint32(len(z.Zones)))
+	for za0001 := range z.Zones {
+		// map header, size 2
+		// string "Name"
+		o = append(o, 0x82, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
+		o = msgp.AppendString(o, z.Zones[za0001].Name)
+		// string "Offset"
+		o = append(o, 0xa6, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74)
+		o = msgp.AppendInt(o, z.Zones[za0001].Offset)
+	}
 	return
 }
 
@@ -626,6 +900,11 @@ func (z *ChartOfAccounts) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "ModifiedBy":
+			z.ModifiedBy, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				return
+			}
 		case "AsOf":
 			z.AsOf, bts, err = msgp.ReadTimeBytes(bts)
 			if err != nil {
@@ -641,6 +920,48 @@ func (z *ChartOfAccounts) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "Zones":
+			var zb0002 uint32
+			zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+			if err != nil {
+				return
+			}
+			if cap(z.Zones) >= int(zb0002) {
+				z.Zones = (z.Zones)[:zb0002]
+			} else {
+				z.Zones = make([]TimeZone, zb0002)
+			}
+			for za0001 := range z.Zones {
+				var zb0003 uint32
+				zb0003, bts, err = msgp.ReadMapHeaderBytes(bts)
+				if err != nil {
+					return
+				}
+				for zb0003 > 0 {
+					zb0003--
+					field, bts, err = msgp.ReadMapKeyZC(bts)
+					if err != nil {
+						return
+					}
+					switch msgp.UnsafeString(field) {
+					case "Name":
+						z.Zones[za0001].Name, bts, err = msgp.ReadStringBytes(bts)
+						if err != nil {
+							return
+						}
+					case "Offset":
+						z.Zones[za0001].Offset, bts, err = msgp.ReadIntBytes(bts)
+						if err != nil {
+							return
+						}
+					default:
+						bts, err = msgp.Skip(bts)
+						if err != nil {
+							return
+						}
+					}
+				}
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -654,7 +975,10 @@ func (z *ChartOfAccounts) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *ChartOfAccounts) Msgsize() (s int) {
-	s = 1 + 3 + msgp.StringPrefixSize + len(z.Id) + 5 + msgp.StringPrefixSize + len(z.Name) + 24 + msgp.StringPrefixSize + len(z.RetainedEarningsAccount) + 5 + msgp.StringPrefixSize + len(z.User) + 5 + msgp.TimeSize + 8 + msgp.TimeSize + 8 + msgp.TimeSize
+	s = 1 + 3 + msgp.StringPrefixSize + len(z.Id) + 5 + msgp.StringPrefixSize + len(z.Name) + 24 + msgp.StringPrefixSize + len(z.RetainedEarningsAccount) + 5 + msgp.StringPrefixSize + len(z.User) + 11 + msgp.StringPrefixSize + len(z.ModifiedBy) + 5 + msgp.TimeSize + 8 + msgp.TimeSize + 8 + msgp.TimeSize + 6 + msgp.ArrayHeaderSize
+	for za0001 := range z.Zones {
+		s += 1 + 5 + msgp.StringPrefixSize + len(z.Zones[za0001].Name) + 7 + msgp.IntSize
+	}
 	return
 }
 
@@ -774,3 +1098,196 @@ func (z ChartsOfAccounts) Msgsize() (s int) {
 	}
 	return
 }
+
+// DecodeMsg implements msgp.Decodable
+func (z *Tags) DecodeMsg(dc *msgp.Reader) (err error) {
+	var zb0002 uint32
+	zb0002, err = dc.ReadArrayHeader()
+	if err != nil {
+		return
+	}
+	if cap((*z)) >= int(zb0002) {
+		(*z) = (*z)[:zb0002]
+	} else {
+		(*z) = make(Tags, zb0002)
+	}
+	for zb0001 := range *z {
+		(*z)[zb0001], err = dc.ReadString()
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z Tags) EncodeMsg(en *msgp.Writer) (err error) {
+	err = en.WriteArrayHeader(uint32(len(z)))
+	if err != nil {
+		return
+	}
+	for zb0003 := range z {
+		err = en.WriteString(z[zb0003])
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z Tags) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	o = msgp.AppendArrayHeader(o, uint32(len(z)))
+	for zb0003 := range z {
+		o = msgp.AppendString(o, z[zb0003])
+	}
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *Tags) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var zb0002 uint32
+	zb0002, bts, err = msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	if cap((*z)) >= int(zb0002) {
+		(*z) = (*z)[:zb0002]
+	} else {
+		(*z) = make(Tags, zb0002)
+	}
+	for zb0001 := range *z {
+		(*z)[zb0001], bts, err = msgp.ReadStringBytes(bts)
+		if err != nil {
+			return
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z Tags) Msgsize() (s int) {
+	s = msgp.ArrayHeaderSize
+	for zb0003 := range z {
+		s += msgp.StringPrefixSize + len(z[zb0003])
+	}
+	return
+}
+
+// DecodeMsg implements msgp.Decodable
+func (z *TimeZone) DecodeMsg(dc *msgp.Reader) (err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, err = dc.ReadMapHeader()
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, err = dc.ReadMapKeyPtr()
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Name":
+			z.Name, err = dc.ReadString()
+			if err != nil {
+				return
+			}
+		case "Offset":
+			z.Offset, err = dc.ReadInt()
+			if err != nil {
+				return
+			}
+		default:
+			err = dc.Skip()
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// EncodeMsg implements msgp.Encodable
+func (z TimeZone) EncodeMsg(en *msgp.Writer) (err error) {
+	// map header, size 2
+	// write "Name"
+	err = en.Append(0x82, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
+	if err != nil {
+		return err
+	}
+	err = en.WriteString(z.Name)
+	if err != nil {
+		return
+	}
+	// write "Offset"
+	err = en.Append(0xa6, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	if err != nil {
+		return err
+	}
+	err = en.WriteInt(z.Offset)
+	if err != nil {
+		return
+	}
+	return
+}
+
+// MarshalMsg implements msgp.Marshaler
+func (z TimeZone) MarshalMsg(b []byte) (o []byte, err error) {
+	o = msgp.Require(b, z.Msgsize())
+	// map header, size 2
+	// string "Name"
+	o = append(o, 0x82, 0xa4, 0x4e, 0x61, 0x6d, 0x65)
+	o = msgp.AppendString(o, z.Name)
+	// string "Offset"
+	o = append(o, 0xa6, 0x4f, 0x66, 0x66, 0x73, 0x65, 0x74)
+	o = msgp.AppendInt(o, z.Offset)
+	return
+}
+
+// UnmarshalMsg implements msgp.Unmarshaler
+func (z *TimeZone) UnmarshalMsg(bts []byte) (o []byte, err error) {
+	var field []byte
+	_ = field
+	var zb0001 uint32
+	zb0001, bts, err = msgp.ReadMapHeaderBytes(bts)
+	if err != nil {
+		return
+	}
+	for zb0001 > 0 {
+		zb0001--
+		field, bts, err = msgp.ReadMapKeyZC(bts)
+		if err != nil {
+			return
+		}
+		switch msgp.UnsafeString(field) {
+		case "Name":
+			z.Name, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				return
+			}
+		case "Offset":
+			z.Offset, bts, err = msgp.ReadIntBytes(bts)
+			if err != nil {
+				return
+			}
+		default:
+			bts, err = msgp.Skip(bts)
+			if err != nil {
+				return
+			}
+		}
+	}
+	o = bts
+	return
+}
+
+// Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
+func (z TimeZone) Msgsize() (s int) {
+	s = 1 + 5 + msgp.StringPrefixSize + len(z.Name) + 7 + msgp.IntSize
+	return
+}
@@ -1,6 +1,9 @@
 package coa
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 )
@@ -95,6 +98,24 @@ func TestIfAllChartsOfAccountsIsSorted(t *testing.T) {
 	}
 }
 
+func TestAllChartsOfAccountsSortsStoredData(t *testing.T) {
+	s := store{}
+	data, err := ChartsOfAccounts{
+		&ChartOfAccounts{Id: "c", Name: "b"},
+		&ChartOfAccounts{Id: "b", Name: "a"},
+		&ChartOfAccounts{Id: "a", Name: "b"},
+	}.MarshalMsg(nil)
+	check(t, err)
+	check(t, s.Put([]byte("charts-of-accounts"), data))
+	coas, err := NewCoaRepository(s).AllChartsOfAccounts()
+	check(t, err)
+	for i, id := range []string{"b", "a", "c"} {
+		if coas[i].Id != id {
+			t.Errorf("Expected %v at %v but was %v", id, i, coas[i].Id)
+		}
+	}
+}
+
 func TestSaveAccount(t *testing.T) {
 	r := NewCoaRepository(store{})
 	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
@@ -167,17 +188,17 @@ func TestIfAllAccountsIsSorted(t *testing.T) {
 	check(t, err)
 	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
 	check(t, err)
-	_, err = r.SaveAccount(coa.Id, &Account{Number: "11", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
 	check(t, err)
 	accounts, err := r.AllAccounts(coa.Id)
 	check(t, err)
-	if accounts[0].Number != "1" || accounts[1].Number != "11" || accounts[2].Number != "2" {
+	if accounts[0].Number != "1" || accounts[1].Number != "1.1" || accounts[2].Number != "2" {
 		t.Errorf("Expected sorted but was %v %v", accounts[0].Number, accounts[1].Number)
 	}
 	idx, err := r.Indexes(coa.Id, []string{accounts[0].Id, accounts[1].Id, accounts[2].Id}, nil)
 	check(t, err)
-	if idx[0] != 0 || idx[1] != 2 || idx[2] != 1 {
-		t.Errorf("Expected 1 0 but was %v %v", idx[0], idx[1])
+	if idx[0] != 0 || idx[1] != 1 || idx[2] != 2 {
+		t.Errorf("Expected 0 1 2 but was %v %v %v", idx[0], idx[1], idx[2])
 	}
 }
 
@@ -186,3 +207,810 @@ func check(t *testing.T, err error) {
 		t.Fatal(err)
 	}
 }
+
+func TestTagsSetOperations(t *testing.T) {
+	tests := []struct {
+		a, b      Tags
+		equal     bool
+		intersect Tags
+		without   Tags
+	}{
+		{nil, nil, true, nil, nil},
+		{Tags{}, Tags{"a"}, false, nil, nil},
+		{Tags{"a"}, Tags{}, false, nil, Tags{"a"}},
+		{Tags{"a", "b"}, Tags{"b", "a"}, true, Tags{"a", "b"}, nil},
+		{Tags{"a", "a", "b"}, Tags{"b", "a"}, true, Tags{"a", "b"}, nil},
+		{Tags{"a", "b", "c"}, Tags{"b", "d"}, false, Tags{"b"}, Tags{"a", "c"}},
+		{Tags{"c", "c", "a"}, Tags{"b"}, false, nil, Tags{"c", "a"}},
+	}
+	for _, test := range tests {
+		if equal := test.a.Equal(test.b); equal != test.equal {
+			t.Errorf("Expected %v.Equal(%v)==%v but was %v", test.a, test.b, test.equal, equal)
+		}
+		if got := test.a.Intersect(test.b); !sameOrder(got, test.intersect) {
+			t.Errorf("Expected %v.Intersect(%v)==%v but was %v", test.a, test.b, test.intersect, got)
+		}
+		if got := test.a.Without(test.b); !sameOrder(got, test.without) {
+			t.Errorf("Expected %v.Without(%v)==%v but was %v", test.a, test.b, test.without, got)
+		}
+	}
+}
+
+func sameOrder(a, b Tags) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestValidateInheritedPropertiesAgainstAncestors(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	accounts[1].Tags = Tags{"incomeStatement", "increaseOnDebit", "summary"}
+	check(t, r.put("accounts/"+coa.Id, accounts))
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "a1.1.1", Parent: a11.Id, Tags: []string{"incomeStatement", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The financial statement must be same as the ancestor 1" {
+		t.Errorf("Expected ancestor conflict but was %v", err)
+	}
+}
+
+func TestInheritParentProperties(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"incomeStatement", "operating", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"increaseOnCredit"}})
+	if err == nil {
+		t.Error("Expected error when inheritance is not enabled")
+	}
+	r.InheritParentProperties = true
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"increaseOnCredit"}})
+	check(t, err)
+	if !a11.Tags.ContainsAll([]string{"incomeStatement", "operating"}) {
+		t.Errorf("Expected inherited properties but was %v", a11.Tags)
+	}
+	if a11.Tags.Contains("increaseOnDebit") {
+		t.Errorf("Expected non-inherited properties not to be copied but was %v", a11.Tags)
+	}
+}
+
+func TestPropertyCatalogs(t *testing.T) {
+	inherited := InheritedProperties()
+	if inherited["balanceSheet"] != "financial statement" {
+		t.Errorf("Expected financial statement but was %v", inherited["balanceSheet"])
+	}
+	delete(inherited, "balanceSheet")
+	if !IsInheritedProperty("balanceSheet") {
+		t.Error("Expected InheritedProperties to return a copy")
+	}
+	if IsInheritedProperty("increaseOnDebit") {
+		t.Error("Expected increaseOnDebit not to be inherited")
+	}
+	if _, ok := NonInheritedProperties()["increaseOnDebit"]; !ok {
+		t.Error("Expected increaseOnDebit in NonInheritedProperties")
+	}
+}
+
+func TestCountAccounts(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	n, err := r.CountAccounts(coa.Id)
+	check(t, err)
+	if n != 0 {
+		t.Errorf("Expected 0 but was %v", n)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	n, err = r.CountAccounts(coa.Id)
+	check(t, err)
+	if n != 2 {
+		t.Errorf("Expected 2 but was %v", n)
+	}
+}
+
+func TestSaveAccountWithDeletedParent(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a111, err := r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "a1.1.1", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	check(t, r.put("accounts/"+coa.Id, accounts[1:]))
+	_, err = r.SaveAccount(coa.Id, a11)
+	if err == nil || err.Error() != "Parent not found: "+a1.Id {
+		t.Errorf("Expected parent not found but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, a111)
+	if err == nil || err.Error() != "Parent not found: "+a1.Id {
+		t.Errorf("Expected ancestor not found but was %v", err)
+	}
+}
+
+func TestSaveRetainedEarningsAccountWithUnknownChart(t *testing.T) {
+	r := NewCoaRepository(store{})
+	_, err := r.SaveAccount("unknown", &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	if err == nil || err.Error() != "Chart of accounts not found: unknown" {
+		t.Errorf("Expected chart not found but was %v", err)
+	}
+}
+
+func TestSaveAccountWithUnknownChart(t *testing.T) {
+	s := store{}
+	r := NewCoaRepository(s)
+	_, err := r.SaveAccount("unknown", &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "Chart of accounts not found: unknown" {
+		t.Errorf("Expected chart not found but was %v", err)
+	}
+	if _, ok := s["accounts/unknown"]; ok {
+		t.Error("Expected no accounts to be written")
+	}
+}
+
+func TestUpdateChartOfAccountsPreservesCreated(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	created := coa.Created
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Id: coa.Id, Name: "coacoa"})
+	check(t, err)
+	coa, err = r.GetChartOfAccounts(coa.Id)
+	check(t, err)
+	if !coa.Created.Equal(created) {
+		t.Errorf("Expected coa.Created==%v but was %v", created, coa.Created)
+	}
+}
+
+func TestChartNames(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa1, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	coa, err := r.GetChartByName("coa")
+	check(t, err)
+	if coa == nil || coa.Id != coa1.Id {
+		t.Errorf("Expected %v but was %v", coa1, coa)
+	}
+	coa, err = r.GetChartByName("unknown")
+	check(t, err)
+	if coa != nil {
+		t.Errorf("Expected nil but was %v", coa)
+	}
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	r.UniqueChartNames = true
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	if _, ok := err.(ValidationError); !ok {
+		t.Errorf("Expected ValidationError but was %v", err)
+	}
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Name: "other"})
+	check(t, err)
+}
+
+func TestChartsForUser(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "a", User: "u1"})
+	check(t, err)
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Name: "b", User: "u2"})
+	check(t, err)
+	coas, err := r.ChartsForUser("u1")
+	check(t, err)
+	if len(coas) != 1 || coas[0].Id != coa.Id {
+		t.Errorf("Expected only chart a but was %v", coas)
+	}
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if a.User != "u1" {
+		t.Errorf("Expected a.User==u1 but was %v", a.User)
+	}
+}
+
+func TestModifiedBy(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa", User: "owner"})
+	check(t, err)
+	if coa.ModifiedBy != "owner" {
+		t.Errorf("Expected coa.ModifiedBy==owner but was %v", coa.ModifiedBy)
+	}
+	coa, err = r.SaveChartOfAccountsAs(coa, "editor")
+	check(t, err)
+	if coa.ModifiedBy != "editor" || coa.User != "owner" {
+		t.Errorf("Expected editor and owner but was %v %v", coa.ModifiedBy, coa.User)
+	}
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if a1.ModifiedBy != "owner" {
+		t.Errorf("Expected a1.ModifiedBy==owner but was %v", a1.ModifiedBy)
+	}
+	_, err = r.SaveAccountAs(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}}, "editor")
+	check(t, err)
+	a1, err = r.GetAccount(coa.Id, a1.Id)
+	check(t, err)
+	if a1.ModifiedBy != "editor" {
+		t.Errorf("Expected parent ModifiedBy==editor but was %v", a1.ModifiedBy)
+	}
+}
+
+func TestValidateAccountDoesNotSave(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a := &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "unknown"}}
+	check(t, r.ValidateAccount(coa.Id, a))
+	if len(a.Tags) != 3 || a.Id != "" {
+		t.Errorf("Expected account to be unchanged but was %v", a)
+	}
+	err = r.ValidateAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"increaseOnDebit"}})
+	if _, ok := err.(ValidationError); !ok {
+		t.Errorf("Expected ValidationError but was %v", err)
+	}
+	n, err := r.CountAccounts(coa.Id)
+	check(t, err)
+	if n != 0 {
+		t.Errorf("Expected no accounts but was %v", n)
+	}
+	if err := r.ValidateChartOfAccounts(&ChartOfAccounts{Name: " "}); err == nil {
+		t.Error("Expected error for empty chart name")
+	}
+	check(t, r.ValidateChartOfAccounts(&ChartOfAccounts{Name: "other"}))
+}
+
+func TestCanConvertToSummary(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	var asked string
+	r.CanConvertToSummary = func(coaid, id string) (bool, error) {
+		asked = id
+		return false, nil
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "Parent has postings and cannot become a summary account" {
+		t.Errorf("Expected conversion to be refused but was %v", err)
+	}
+	if asked != a1.Id {
+		t.Errorf("Expected hook to be called with %v but was %v", a1.Id, asked)
+	}
+	n, err := r.CountAccounts(coa.Id)
+	check(t, err)
+	if n != 1 {
+		t.Errorf("Expected child not to be written but count was %v", n)
+	}
+	r.CanConvertToSummary = func(coaid, id string) (bool, error) { return true, nil }
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+}
+
+func TestDeterministicStorage(t *testing.T) {
+	s := store{}
+	r := NewCoaRepositoryWithClock(s, fixedClock(time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)))
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"increaseOnDebit", "balanceSheet"}})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "balanceSheet"}})
+	check(t, err)
+	before := string(s["accounts/"+coa.Id])
+	a1, err = r.GetAccount(coa.Id, a1.Id)
+	check(t, err)
+	a1.Tags = Tags{"increaseOnDebit", "detail", "balanceSheet"}
+	_, err = r.SaveAccount(coa.Id, a1)
+	check(t, err)
+	if after := string(s["accounts/"+coa.Id]); after != before {
+		t.Errorf("Expected stored bytes to be equal\n%q\n%q", before, after)
+	}
+}
+
+func TestTagsNormalize(t *testing.T) {
+	tags := Tags{"b", "a", "b", "c", "a"}.normalize()
+	if !sameOrder(tags, Tags{"a", "b", "c"}) {
+		t.Errorf("Expected [a b c] but was %v", tags)
+	}
+}
+
+func TestKeyPrefix(t *testing.T) {
+	s := store{}
+	r1 := NewCoaRepositoryWithPrefix(s, "tenantA/")
+	r2 := NewCoaRepositoryWithPrefix(s, "tenantB/")
+	coa, err := r1.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r1.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if _, ok := s["tenantA/charts-of-accounts"]; !ok {
+		t.Error("Expected charts to be stored under tenantA/")
+	}
+	if _, ok := s["tenantA/accounts/"+coa.Id]; !ok {
+		t.Error("Expected accounts to be stored under tenantA/")
+	}
+	coas, err := r2.AllChartsOfAccounts()
+	check(t, err)
+	if len(coas) != 0 {
+		t.Errorf("Expected tenantB to be empty but was %v", len(coas))
+	}
+	n, err := r2.CountAccounts(coa.Id)
+	check(t, err)
+	if n != 0 {
+		t.Errorf("Expected tenantB to have no accounts but was %v", n)
+	}
+}
+
+func TestExplicitTags(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "summary"}})
+	if err == nil {
+		t.Error("Expected error when detail is added to a summary account")
+	}
+	r.ExplicitTags = true
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "summary"}})
+	check(t, err)
+	if a1.IsDetail() || !a1.IsSummary() {
+		t.Errorf("Expected a1 to be summary only but was %v", a1.Tags)
+	}
+}
+
+func TestSaveAccountWithResult(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	result, err := r.SaveAccountWithResult(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if result.Account.Id == "" || result.ChangedParent != nil || result.ChangedChart != nil {
+		t.Errorf("Expected only the account in the result but was %v", result)
+	}
+	a1 := result.Account
+	result, err = r.SaveAccountWithResult(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	check(t, err)
+	if result.ChangedParent == nil || result.ChangedParent.Id != a1.Id || !result.ChangedParent.IsSummary() {
+		t.Errorf("Expected a1 as summary parent but was %v", result.ChangedParent)
+	}
+	if result.ChangedChart == nil || result.ChangedChart.RetainedEarningsAccount != result.Account.Id {
+		t.Errorf("Expected chart with retained earnings account but was %v", result.ChangedChart)
+	}
+	result, err = r.SaveAccountWithResult(coa.Id, result.Account)
+	check(t, err)
+	if result.ChangedParent != nil || result.ChangedChart != nil {
+		t.Errorf("Expected nothing else to change but was %v", result)
+	}
+}
+
+func TestCreatedAndRemovedAreSerialized(t *testing.T) {
+	created := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	removed := created.Add(time.Hour)
+	a := &Account{Number: "1", Name: "a1", Created: created, Removed: removed}
+	data, err := a.MarshalMsg(nil)
+	check(t, err)
+	var msg Account
+	_, err = msg.UnmarshalMsg(data)
+	check(t, err)
+	if !msg.Created.Equal(created) || !msg.Removed.Equal(removed) {
+		t.Errorf("Expected %v and %v but was %v and %v", created, removed, msg.Created, msg.Removed)
+	}
+	data, err = json.Marshal(a)
+	check(t, err)
+	var js Account
+	check(t, json.Unmarshal(data, &js))
+	if !js.Created.Equal(created) || !js.Removed.Equal(removed) {
+		t.Errorf("Expected %v and %v but was %v and %v", created, removed, js.Created, js.Removed)
+	}
+	coa := &ChartOfAccounts{Name: "coa", Created: created, Removed: removed}
+	data, err = json.Marshal(coa)
+	check(t, err)
+	var jsCoa ChartOfAccounts
+	check(t, json.Unmarshal(data, &jsCoa))
+	if !jsCoa.Created.Equal(created) || !jsCoa.Removed.Equal(removed) {
+		t.Errorf("Expected %v and %v but was %v and %v", created, removed, jsCoa.Created, jsCoa.Removed)
+	}
+}
+
+func TestEqualIgnoringTimestamps(t *testing.T) {
+	a := &Account{Id: "1", Number: "1", Name: "a1", Tags: []string{"detail", "balanceSheet"}, AsOf: time.Now()}
+	b := &Account{Id: "1", Number: "1", Name: "a1", Tags: []string{"balanceSheet", "detail"}, Created: time.Now()}
+	if !a.EqualIgnoringTimestamps(b) {
+		t.Errorf("Expected %v to equal %v", a, b)
+	}
+	b.Name = "b1"
+	if a.EqualIgnoringTimestamps(b) {
+		t.Errorf("Expected %v not to equal %v", a, b)
+	}
+	if a.EqualIgnoringTimestamps(nil) {
+		t.Errorf("Expected %v not to equal nil", a)
+	}
+}
+
+func TestSkipUnchangedAccounts(t *testing.T) {
+	first := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	r := NewCoaRepositoryWithClock(store{}, fixedClock(first))
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	later := first.Add(time.Hour)
+	r.Clock = fixedClock(later)
+	r.SkipUnchangedAccounts = true
+	saved, err := r.SaveAccount(coa.Id, &Account{Id: a.Id, Number: "1", Name: "a1", Tags: []string{"increaseOnDebit", "balanceSheet", "detail"}})
+	check(t, err)
+	if !saved.AsOf.Equal(first) {
+		t.Errorf("Expected %v but was %v", first, saved.AsOf)
+	}
+	saved, err = r.SaveAccount(coa.Id, &Account{Id: a.Id, Number: "1", Name: "renamed", Tags: []string{"increaseOnDebit", "balanceSheet", "detail"}})
+	check(t, err)
+	if !saved.AsOf.Equal(later) {
+		t.Errorf("Expected %v but was %v", later, saved.AsOf)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Id: a.Id, Number: "1", Name: "renamed", Tags: []string{"increaseOnDebit", "balanceSheet", "detail", "retainedEarnings"}})
+	check(t, err)
+	coa, err = r.GetChartOfAccounts(coa.Id)
+	check(t, err)
+	if coa.RetainedEarningsAccount != a.Id {
+		t.Errorf("Expected %v but was %v", a.Id, coa.RetainedEarningsAccount)
+	}
+	r.SkipUnchangedAccounts = false
+	r.Clock = fixedClock(later.Add(time.Hour))
+	saved, err = r.SaveAccount(coa.Id, &Account{Id: a.Id, Number: "1", Name: "renamed", Tags: []string{"increaseOnDebit", "balanceSheet", "detail"}})
+	check(t, err)
+	if !saved.AsOf.Equal(later.Add(time.Hour)) {
+		t.Errorf("Expected %v but was %v", later.Add(time.Hour), saved.AsOf)
+	}
+}
+
+func TestClone(t *testing.T) {
+	a := &Account{Id: "1", Number: "1", Name: "a1", Tags: []string{"balanceSheet", "detail"}}
+	clone := a.Clone()
+	clone.Name = "b1"
+	clone.Tags[0] = "incomeStatement"
+	if a.Name != "a1" || a.Tags[0] != "balanceSheet" {
+		t.Errorf("Expected the original to be unchanged but was %v", a)
+	}
+	coa := &ChartOfAccounts{Id: "1", Name: "coa"}
+	coaClone := coa.Clone()
+	coaClone.Name = "other"
+	if coa.Name != "coa" {
+		t.Errorf("Expected the original to be unchanged but was %v", coa)
+	}
+	if (*Account)(nil).Clone() != nil || (*ChartOfAccounts)(nil).Clone() != nil {
+		t.Error("Expected nil clones of nil")
+	}
+}
+
+func TestCreateAndUpdateAccount(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	result, err := r.SaveAccountWithResult(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if !result.Created {
+		t.Error("Expected the save to be a create")
+	}
+	result, err = r.SaveAccountWithResult(coa.Id, result.Account)
+	check(t, err)
+	if result.Created {
+		t.Error("Expected the save to be an update")
+	}
+	a1 := result.Account
+	if _, err := r.CreateAccount(coa.Id, a1); err == nil {
+		t.Error("Expected CreateAccount to reject an account with id")
+	}
+	a2, err := r.CreateAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if a2.Id == "" {
+		t.Error("Expected CreateAccount to assign an id")
+	}
+	if _, err := r.UpdateAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"balanceSheet", "increaseOnDebit"}}); err == nil {
+		t.Error("Expected UpdateAccount to reject an account without id")
+	}
+	_, err = r.UpdateAccount(coa.Id, &Account{Id: "bogus", Number: "3", Name: "a3", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+	a2.Name = "renamed"
+	_, err = r.UpdateAccount(coa.Id, a2)
+	check(t, err)
+	a2, err = r.GetAccount(coa.Id, a2.Id)
+	check(t, err)
+	if a2.Name != "renamed" {
+		t.Errorf("Expected renamed but was %v", a2.Name)
+	}
+}
+
+func TestCreateAndUpdateChart(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.CreateChart(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if coa.Id == "" {
+		t.Error("Expected CreateChart to assign an id")
+	}
+	if _, err := r.CreateChart(&ChartOfAccounts{Id: coa.Id, Name: "other"}); err == nil {
+		t.Error("Expected CreateChart to reject a chart with an id in use")
+	}
+	preset, err := r.CreateChart(&ChartOfAccounts{Id: "preset", Name: "preset"})
+	check(t, err)
+	if preset.Id != "preset" {
+		t.Errorf("Expected preset but was %v", preset.Id)
+	}
+	if _, err := r.UpdateChart(&ChartOfAccounts{Name: "other"}); err == nil {
+		t.Error("Expected UpdateChart to reject a chart without id")
+	}
+	_, err = r.UpdateChart(&ChartOfAccounts{Id: "bogus", Name: "other"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+	coa.Name = "renamed"
+	_, err = r.UpdateChart(coa)
+	check(t, err)
+	coas, err := r.AllChartsOfAccounts()
+	check(t, err)
+	if len(coas) != 2 || coas[0].Name != "preset" || coas[1].Name != "renamed" {
+		t.Errorf("Expected the preset and renamed charts but was %v", coas)
+	}
+}
+
+func TestChildIsNotWrittenWhenParentIsInvalid(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	check(t, r.put("accounts/"+coa.Id, Accounts{
+		&Account{Id: "a1", Number: "1", Name: "a1", Tags: []string{"balanceSheet", "detail"}},
+	}))
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The normal balance must be informed" {
+		t.Errorf("Expected the parent to be rejected but was %v", err)
+	}
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 1 || !accounts[0].IsDetail() {
+		t.Errorf("Expected only the unchanged parent but was %v", accounts)
+	}
+}
+
+func TestIgnoredTags(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"assets", "debit"}})
+	if err == nil || err.Error() != "The financial statement must be informed (ignored unknown tags: assets, debit)" {
+		t.Errorf("Expected the ignored tags in the error but was %v", err)
+	}
+	result, err := r.SaveAccountWithResult(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "assets"}})
+	check(t, err)
+	if len(result.IgnoredTags) != 1 || result.IgnoredTags[0] != "assets" || result.Account.Tags.Contains("assets") {
+		t.Errorf("Expected assets to be ignored but was %v %v", result.IgnoredTags, result.Account.Tags)
+	}
+}
+
+func TestAccountString(t *testing.T) {
+	a1 := &Account{Id: "a1", Number: "1", Name: "Assets", Tags: []string{"summary", "balanceSheet", "increaseOnDebit"}, AsOf: time.Now()}
+	a2 := &Account{Id: "a2", Number: "1.1", Name: "Cash", Parent: "a1"}
+	if s := a1.String(); s != "1 Assets [balanceSheet increaseOnDebit summary]" {
+		t.Errorf("Unexpected format %q", s)
+	}
+	if s := (Accounts{a1, a2}).String(); s != "1 Assets [balanceSheet increaseOnDebit summary]\n1.1 Cash []" {
+		t.Errorf("Unexpected format %q", s)
+	}
+}
+
+func TestGetChartsOfAccounts(t *testing.T) {
+	r := NewCoaRepository(store{})
+	var ids []string
+	for _, name := range []string{"a", "b", "c"} {
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: name})
+		check(t, err)
+		ids = append(ids, coa.Id)
+	}
+	coas, err := r.GetChartsOfAccounts([]string{ids[2], "bogus", ids[0]})
+	check(t, err)
+	if len(coas) != 2 || coas[0].Name != "c" || coas[1].Name != "a" {
+		t.Errorf("Expected c and a but was %v", coas)
+	}
+}
+
+type strictStore map[string][]byte
+
+func (s strictStore) Get(key []byte) ([]byte, error) {
+	b, ok := s[string(key)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", key, ErrKeyNotFound)
+	}
+	return b, nil
+}
+
+func (s strictStore) Put(key []byte, value []byte) error {
+	s[string(key)] = value
+	return nil
+}
+
+func TestStoreReportingMissingKeys(t *testing.T) {
+	s := strictStore{}
+	r := NewCoaRepository(s)
+	coas, err := r.AllChartsOfAccounts()
+	check(t, err)
+	if len(coas) != 0 {
+		t.Errorf("Expected no charts but was %v", coas)
+	}
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	n, err := r.CountAccounts(coa.Id)
+	check(t, err)
+	if n != 0 {
+		t.Errorf("Expected 0 but was %v", n)
+	}
+	check(t, r.put("accounts/"+coa.Id, Accounts{}))
+	if len(s["accounts/"+coa.Id]) == 0 {
+		t.Error("Expected an empty list to be stored as a non-empty value")
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+}
+
+func TestParentCycles(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	self := &Account{Id: a1.Id, Number: "1", Name: "a1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit", "summary"}}
+	if msg := self.ValidationMessage(coa.Id, r); msg != "An account cannot be its own parent" {
+		t.Errorf("Expected a self reference error but was %q", msg)
+	}
+	cycle := &Account{Id: a1.Id, Number: "1", Name: "a1", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit", "summary"}}
+	if msg := cycle.ValidationMessage(coa.Id, r); msg != "The parent cannot be a descendant of the account" {
+		t.Errorf("Expected a cycle error but was %q", msg)
+	}
+}
+
+func TestRetainedEarningsTag(t *testing.T) {
+	r := NewCoaRepository(store{})
+	r.RetainedEarningsTag = "lucrosAcumulados"
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	result, err := r.SaveAccountWithResult(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	check(t, err)
+	if result.ChangedChart != nil || len(result.IgnoredTags) != 1 {
+		t.Errorf("Expected the default tag to be ignored but was %v", result)
+	}
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnCredit", "lucrosAcumulados"}})
+	check(t, err)
+	if a2.Tags.Contains("lucrosAcumulados") {
+		t.Errorf("Expected the tag not to be stored but was %v", a2.Tags)
+	}
+	coa, err = r.GetChartOfAccounts(coa.Id)
+	check(t, err)
+	if coa.RetainedEarningsAccount != a2.Id {
+		t.Errorf("Expected %v but was %v", a2.Id, coa.RetainedEarningsAccount)
+	}
+}
+
+func TestSaveMissingChart(t *testing.T) {
+	r := NewCoaRepository(store{})
+	_, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Id: "bogus", Name: "other"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+	coas, err := r.AllChartsOfAccounts()
+	check(t, err)
+	if len(coas) != 1 || coas[0].Name != "coa" {
+		t.Errorf("Expected only coa but was %v", coas)
+	}
+}
+
+func TestNormalizeSpace(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: " coa "})
+	check(t, err)
+	if coa.Name != "coa" {
+		t.Errorf("Expected coa but was %q", coa.Name)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1 ", Name: "  Cash  and  banks", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: " 1", Name: "Cash", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "An account with this number already exists" {
+		t.Errorf("Expected duplicate number but was %v", err)
+	}
+	r.CollapseWhitespace = true
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: " Accounts \t receivable ", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	aa, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(aa) != 2 || aa[0].Number != "1" || aa[0].Name != "Cash  and  banks" || aa[1].Name != "Accounts receivable" {
+		t.Errorf("Expected normalized numbers and names but was %q", aa)
+	}
+}
+
+func TestPreserveTimestamps(t *testing.T) {
+	now := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	created := now.AddDate(-5, 0, 0)
+	r := NewCoaRepositoryWithClock(store{}, fixedClock(now))
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Created: created, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if !a1.Created.Equal(now) {
+		t.Errorf("Expected %v but was %v", now, a1.Created)
+	}
+	r.PreserveTimestamps = true
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Created: created, AsOf: created.AddDate(1, 0, 0), Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if !a2.Created.Equal(created) || !a2.AsOf.Equal(created.AddDate(1, 0, 0)) {
+		t.Errorf("Expected preserved timestamps but was %v %v", a2.Created, a2.AsOf)
+	}
+	a3, err := r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if !a3.Created.Equal(now) || !a3.AsOf.Equal(now) {
+		t.Errorf("Expected %v but was %v %v", now, a3.Created, a3.AsOf)
+	}
+	a2.Name = "renamed"
+	a2, err = r.SaveAccount(coa.Id, a2)
+	check(t, err)
+	if !a2.Created.Equal(created) || !a2.AsOf.Equal(now) {
+		t.Errorf("Expected update to keep Created and set AsOf but was %v %v", a2.Created, a2.AsOf)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "4", Name: "a4", Created: now.Add(time.Hour), Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The creation date must not be in the future" {
+		t.Errorf("Expected a future creation date error but was %v", err)
+	}
+}
+
+func TestAutoManageDetailSummary(t *testing.T) {
+	for _, auto := range []bool{true, false} {
+		r := NewCoaRepository(store{})
+		r.AutoManageDetailSummary = auto
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		result, err := r.SaveAccountWithResult(coa.Id, &Account{Number: "1.1", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		a1, err = r.GetAccount(coa.Id, a1.Id)
+		check(t, err)
+		if auto {
+			if !a1.IsSummary() || a1.IsDetail() || !result.Account.IsDetail() || result.ChangedParent == nil {
+				t.Errorf("Expected a summary parent and a detail child but was %v and %v", a1, result.Account)
+			}
+		} else if a1.IsSummary() || a1.IsDetail() || result.Account.IsDetail() || result.ChangedParent != nil {
+			t.Errorf("Expected the tags untouched but was %v and %v", a1, result.Account)
+		}
+	}
+}
+
+func TestScopeByUser(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa", User: "alice"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", User: "bob", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	r.ScopeByUser = true
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.2", Name: "a12", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The parent must belong to the same user" {
+		t.Errorf("Expected a user mismatch but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.2", Name: "a12", User: "bob", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+}
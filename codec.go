@@ -0,0 +1,58 @@
+package coa
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// Codec encodes the values the repository stores: Accounts, *Account,
+// ChartsOfAccounts and the account index of the per-account layout.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// msgpCodec is the default codec.
+type msgpCodec struct{}
+
+func (msgpCodec) Marshal(v interface{}) ([]byte, error) {
+	m, ok := v.(msgp.Marshaler)
+	if !ok {
+		return nil, fmt.Errorf("Invalid argument: can't encode %T", v)
+	}
+	return m.MarshalMsg(nil)
+}
+
+func (msgpCodec) Unmarshal(data []byte, v interface{}) error {
+	u, ok := v.(msgp.Unmarshaler)
+	if !ok {
+		return fmt.Errorf("Invalid argument: can't decode %T", v)
+	}
+	_, err := u.UnmarshalMsg(data)
+	return err
+}
+
+// GobCodec stores values with encoding/gob.
+type GobCodec struct{}
+
+func (GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (r *CoaRepository) codec() Codec {
+	if r.Codec == nil {
+		return msgpCodec{}
+	}
+	return r.Codec
+}
@@ -0,0 +1,59 @@
+package coa
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGobCodecRoundTrip(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("BRT", -3*60*60))
+	aa := Accounts{
+		&Account{Id: "a1", Number: "1", Name: "a1", Tags: []string{"balanceSheet"}, Created: created},
+		&Account{Id: "a2", Number: "2", Name: "a2", Parent: "a1", Removed: created.Add(time.Hour)},
+	}
+	data, err := GobCodec{}.Marshal(aa)
+	check(t, err)
+	var decoded Accounts
+	check(t, GobCodec{}.Unmarshal(data, &decoded))
+	if len(decoded) != 2 || !decoded[0].Created.Equal(created) || !decoded[1].Removed.Equal(created.Add(time.Hour)) || decoded[1].Parent != "a1" {
+		t.Errorf("Expected %v but was %v", aa, decoded)
+	}
+	coas := ChartsOfAccounts{&ChartOfAccounts{Id: "c1", Name: "coa", AsOf: created}}
+	data, err = GobCodec{}.Marshal(coas)
+	check(t, err)
+	var decodedCoas ChartsOfAccounts
+	check(t, GobCodec{}.Unmarshal(data, &decodedCoas))
+	if len(decodedCoas) != 1 || decodedCoas[0].Name != "coa" || !decodedCoas[0].AsOf.Equal(created) {
+		t.Errorf("Expected %v but was %v", coas, decodedCoas)
+	}
+}
+
+func TestGobCodecRepository(t *testing.T) {
+	for _, perAccount := range []bool{false, true} {
+		clock := fixedClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("BRT", -3*60*60)))
+		r := NewCoaRepositoryWithClock(store{}, clock)
+		r.Codec = GobCodec{}
+		r.PerAccount = perAccount
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		aa, err := r.AllAccounts(coa.Id)
+		check(t, err)
+		if len(aa) != 2 || aa[0].Number != "1" || aa[1].Number != "1.1" || !aa[0].Created.Equal(time.Time(clock)) {
+			t.Errorf("Expected accounts 1 and 1.1 but was %v", aa)
+		}
+		n, err := r.CountAccounts(coa.Id)
+		check(t, err)
+		if n != 2 {
+			t.Errorf("Expected 2 accounts but was %v", n)
+		}
+		coas, err := r.AllChartsOfAccounts()
+		check(t, err)
+		if len(coas) != 1 || coas[0].Name != "coa" {
+			t.Errorf("Expected chart coa but was %v", coas)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package coa
+
+// DefaultNormalBalances returns the mapping suggested for
+// CoaRepository.NormalBalances: operating income statement accounts
+// increase on credit. Balance sheet accounts get no default, since
+// liabilities and equity increase on credit while assets increase on debit.
+func DefaultNormalBalances() map[string]string {
+	return map[string]string{
+		"operating": "increaseOnCredit",
+	}
+}
+
+// defaultNormalBalance returns the normal balance the mapping assigns to the
+// tags, or "" when none or conflicting ones apply.
+func defaultNormalBalance(tags Tags, mapping map[string]string) string {
+	result := ""
+	for _, tag := range tags {
+		nb := mapping[tag]
+		if nb == "" {
+			continue
+		}
+		if result != "" && result != nb {
+			return ""
+		}
+		result = nb
+	}
+	return result
+}
@@ -0,0 +1,36 @@
+package coa
+
+import "testing"
+
+func TestNormalBalances(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if _, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet"}}); err == nil {
+		t.Error("Expected a missing normal balance to be rejected by default")
+	}
+	r.NormalBalances = DefaultNormalBalances()
+	if _, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet"}}); err == nil {
+		t.Error("Expected balance sheet accounts to get no default normal balance")
+	}
+	r.NormalBalances = map[string]string{"balanceSheet": "increaseOnDebit"}
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet"}})
+	check(t, err)
+	if !a1.Tags.Contains("increaseOnDebit") {
+		t.Errorf("Expected increaseOnDebit but was %v", a1.Tags)
+	}
+	r.NormalBalances = DefaultNormalBalances()
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "operating"}})
+	check(t, err)
+	if !a2.Tags.Contains("increaseOnCredit") {
+		t.Errorf("Expected increaseOnCredit but was %v", a2.Tags)
+	}
+	a3, err := r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"balanceSheet", "increaseOnCredit"}})
+	check(t, err)
+	if a3.Tags.Contains("increaseOnDebit") {
+		t.Errorf("Expected the explicit normal balance to be kept but was %v", a3.Tags)
+	}
+	if _, err := r.SaveAccount(coa.Id, &Account{Number: "4", Name: "a4", Tags: []string{"incomeStatement"}}); err == nil {
+		t.Error("Expected an unmapped account without normal balance to be rejected")
+	}
+}
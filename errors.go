@@ -0,0 +1,24 @@
+package coa
+
+import "errors"
+
+var ErrNotFound = errors.New("not found")
+
+// ErrKeyNotFound may be returned by a KeyValueStore's Get for a missing key.
+var ErrKeyNotFound = errors.New("key not found")
+
+// ErrRemoved is returned when saving an account that was removed; it must
+// be restored with RestoreAccount first.
+var ErrRemoved = errors.New("is removed")
+
+// ErrDanglingReference is returned when a stored id refers to an account
+// that doesn't exist, which means the data is corrupt. Repair can fix it.
+var ErrDanglingReference = errors.New("refers to a missing account")
+
+// ErrReadOnly is returned by every write through a repository returned by
+// ReadOnly.
+var ErrReadOnly = errors.New("repository is read-only")
+
+type ValidationError string
+
+func (e ValidationError) Error() string { return string(e) }
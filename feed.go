@@ -0,0 +1,115 @@
+package coa
+
+import "sync"
+
+// Kinds of entity in a ChangeEvent.
+const (
+	ChartKind   = "chart"
+	AccountKind = "account"
+)
+
+// Operations in a ChangeEvent.
+const (
+	CreateOperation = "create"
+	UpdateOperation = "update"
+	DeleteOperation = "delete"
+)
+
+// ChangeEvent describes a chart or account written by a CoaRepository. For
+// a chart, Coaid and Id are both its id.
+type ChangeEvent struct {
+	Coaid     string
+	Kind      string
+	Id        string
+	Operation string
+}
+
+// changeFeedBuffer is how many events a subscriber may fall behind before
+// the oldest ones are dropped.
+const changeFeedBuffer = 64
+
+type changeFeed struct {
+	mu          sync.Mutex
+	subscribers []chan ChangeEvent
+	closed      bool
+}
+
+// Subscribe returns a channel receiving an event after each chart or account
+// is written. Saves never wait for subscribers: one that falls behind loses
+// its oldest events. Raw imports and layout migrations publish no events.
+// After Close the channel is returned already closed.
+func (r *CoaRepository) Subscribe() <-chan ChangeEvent {
+	r.feed.mu.Lock()
+	defer r.feed.mu.Unlock()
+	ch := make(chan ChangeEvent, changeFeedBuffer)
+	if r.feed.closed {
+		close(ch)
+		return ch
+	}
+	r.feed.subscribers = append(r.feed.subscribers, ch)
+	return ch
+}
+
+// Unsubscribe stops the events sent to a channel returned by Subscribe and
+// closes it.
+func (r *CoaRepository) Unsubscribe(ch <-chan ChangeEvent) {
+	r.feed.mu.Lock()
+	defer r.feed.mu.Unlock()
+	for i, each := range r.feed.subscribers {
+		if each == ch {
+			r.feed.subscribers = append(r.feed.subscribers[:i], r.feed.subscribers[i+1:]...)
+			close(each)
+			return
+		}
+	}
+}
+
+// close closes the channels of every subscriber and stops publishing.
+func (f *changeFeed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ch := range f.subscribers {
+		close(ch)
+	}
+	f.subscribers = nil
+	f.closed = true
+}
+
+func (r *CoaRepository) publish(e ChangeEvent) {
+	if r.feed == nil {
+		return
+	}
+	r.feed.mu.Lock()
+	defer r.feed.mu.Unlock()
+	for _, ch := range r.feed.subscribers {
+		select {
+		case ch <- e:
+		default:
+			// The subscriber is behind: drop its oldest event. Only publish
+			// sends, under the lock, so there is room afterwards.
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- e
+		}
+	}
+}
+
+func (r *CoaRepository) publishChart(coa *ChartOfAccounts, operation string) {
+	r.publish(ChangeEvent{Coaid: coa.Id, Kind: ChartKind, Id: coa.Id, Operation: operation})
+}
+
+func (r *CoaRepository) publishAccounts(coaid, operation string, accounts ...*Account) {
+	for _, a := range accounts {
+		r.publish(ChangeEvent{Coaid: coaid, Kind: AccountKind, Id: a.Id, Operation: operation})
+	}
+}
+
+// saveOperation returns CreateOperation or UpdateOperation.
+func saveOperation(created bool) string {
+	if created {
+		return CreateOperation
+	}
+	return UpdateOperation
+}
@@ -0,0 +1,52 @@
+package coa
+
+import "testing"
+
+func TestChangeFeed(t *testing.T) {
+	r := NewCoaRepository(store{})
+	ch := r.Subscribe()
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	check(t, r.DeleteAccount(coa.Id, a11.Id))
+	expected := []ChangeEvent{
+		{coa.Id, ChartKind, coa.Id, CreateOperation},
+		{coa.Id, AccountKind, a1.Id, CreateOperation},
+		{coa.Id, AccountKind, a11.Id, CreateOperation},
+		{coa.Id, AccountKind, a1.Id, UpdateOperation},
+		{coa.Id, AccountKind, a11.Id, DeleteOperation},
+	}
+	for _, e := range expected {
+		if got := <-ch; got != e {
+			t.Errorf("Expected %v but was %v", e, got)
+		}
+	}
+	r.Unsubscribe(ch)
+	if _, ok := <-ch; ok {
+		t.Error("Expected the channel to be closed")
+	}
+}
+
+func TestSlowSubscriberDoesNotBlock(t *testing.T) {
+	r := NewCoaRepository(store{})
+	ch := r.Subscribe()
+	var last *ChartOfAccounts
+	for i := 0; i < changeFeedBuffer+10; i++ {
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		last = coa
+	}
+	if len(ch) != changeFeedBuffer {
+		t.Errorf("Expected a full buffer but was %v", len(ch))
+	}
+	var e ChangeEvent
+	for len(ch) > 0 {
+		e = <-ch
+	}
+	if e.Id != last.Id {
+		t.Errorf("Expected the newest event to be kept but was %v", e)
+	}
+}
@@ -0,0 +1,53 @@
+package coa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// ChartFingerprint returns a SHA-256 of the chart's structure: its name and
+// the number, name, tags and parent number of each active account. Ids,
+// owners and timestamps are left out, so charts with the same structure
+// have the same fingerprint no matter how they were built.
+func (r *CoaRepository) ChartFingerprint(coaid string) (string, error) {
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return "", err
+	}
+	if coa == nil {
+		return "", fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return "", err
+	}
+	numbers := map[string]string{}
+	for _, a := range aa {
+		numbers[a.Id] = a.Number
+	}
+	var canonical Accounts
+	for _, a := range aa {
+		if !a.Removed.IsZero() {
+			continue
+		}
+		canonical = append(canonical, &Account{
+			Number: a.Number,
+			Name:   a.Name,
+			Tags:   append(Tags(nil), a.Tags...).normalize(),
+			Parent: numbers[a.Parent],
+		})
+	}
+	canonical.sortByNumber(r.Separator)
+	chart := &ChartOfAccounts{Name: coa.Name, RetainedEarningsAccount: numbers[coa.RetainedEarningsAccount]}
+	data, err := chart.MarshalMsg(nil)
+	if err != nil {
+		return "", err
+	}
+	data, err = canonical.MarshalMsg(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
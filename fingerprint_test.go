@@ -0,0 +1,40 @@
+package coa
+
+import "testing"
+
+func TestChartFingerprint(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa1, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa1.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa1.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa1.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnCredit"}})
+	check(t, err)
+
+	coa2, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa2.Id, &Account{Number: "2", Name: "a2", Tags: []string{"increaseOnCredit", "incomeStatement"}})
+	check(t, err)
+	b1, err := r.SaveAccount(coa2.Id, &Account{Number: "1", Name: "a1", Tags: []string{"increaseOnDebit", "balanceSheet"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa2.Id, &Account{Number: "1.1", Name: "a1.1", Parent: b1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+
+	f1, err := r.ChartFingerprint(coa1.Id)
+	check(t, err)
+	f2, err := r.ChartFingerprint(coa2.Id)
+	check(t, err)
+	if f1 != f2 {
+		t.Errorf("Expected equal fingerprints but was %v %v", f1, f2)
+	}
+	coa2.Name = "other"
+	_, err = r.SaveChartOfAccounts(coa2)
+	check(t, err)
+	f2, err = r.ChartFingerprint(coa2.Id)
+	check(t, err)
+	if f1 == f2 {
+		t.Error("Expected different fingerprints after rename")
+	}
+}
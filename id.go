@@ -0,0 +1,68 @@
+package coa
+
+import (
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+type IDGenerator interface {
+	NewID() (string, error)
+}
+
+// AccountIDGenerator may be implemented by an IDGenerator that derives the
+// ids of accounts from their numbers. SaveAccount then treats a new account
+// whose derived id already exists as an update of that account, so running
+// an import twice doesn't duplicate accounts; CreateAccount rejects it.
+// Changing the number of an account doesn't change its id, but an account
+// created later with the old number can't reuse it.
+type AccountIDGenerator interface {
+	AccountID(number string) (string, error)
+}
+
+// DeterministicIDGenerator generates random ids for charts and, for
+// accounts, version 5 UUIDs of their numbers in Namespace.
+type DeterministicIDGenerator struct {
+	Namespace uuid.UUID
+}
+
+func (DeterministicIDGenerator) NewID() (string, error) {
+	return uuidGenerator{}.NewID()
+}
+
+func (g DeterministicIDGenerator) AccountID(number string) (string, error) {
+	return uuid.NewV5(g.Namespace, number).String(), nil
+}
+
+type uuidGenerator struct{}
+
+func (uuidGenerator) NewID() (string, error) {
+	id, err := uuid.NewV4()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
+
+func (r *CoaRepository) newID() (string, error) {
+	id, err := r.IDGenerator.NewID()
+	if err != nil {
+		return "", fmt.Errorf("Unable to generate id: %w", err)
+	}
+	return id, nil
+}
+
+// newAccountID returns the id of a new account with the number and whether
+// it was derived from the number.
+func (r *CoaRepository) newAccountID(number string) (string, bool, error) {
+	g, ok := r.IDGenerator.(AccountIDGenerator)
+	if !ok {
+		id, err := r.newID()
+		return id, false, err
+	}
+	id, err := g.AccountID(number)
+	if err != nil {
+		return "", false, fmt.Errorf("Unable to generate id: %w", err)
+	}
+	return id, true, nil
+}
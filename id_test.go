@@ -0,0 +1,112 @@
+package coa
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+type sequentialGenerator struct{ next int }
+
+func (g *sequentialGenerator) NewID() (string, error) {
+	g.next++
+	return strconv.Itoa(g.next), nil
+}
+
+func TestRepositoryIDGenerator(t *testing.T) {
+	r := NewCoaRepository(store{})
+	r.IDGenerator = &sequentialGenerator{}
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if coa.Id != "1" {
+		t.Errorf("Expected coa.Id==1 but was %v", coa.Id)
+	}
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if a.Id != "2" {
+		t.Errorf("Expected a.Id==2 but was %v", a.Id)
+	}
+}
+
+func TestSaveGeneratesUUIDv4(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	for _, id := range []string{coa.Id, a.Id} {
+		u, err := uuid.FromString(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u.Version() != uuid.V4 {
+			t.Errorf("Expected version 4 but was %v", u.Version())
+		}
+	}
+}
+
+type failingGenerator struct{}
+
+func (failingGenerator) NewID() (string, error) { return "", errors.New("no entropy") }
+
+func TestSaveFailsWhenIDGenerationFails(t *testing.T) {
+	s := store{}
+	r := NewCoaRepository(s)
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	r.IDGenerator = failingGenerator{}
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Name: "other"})
+	if err == nil || !strings.Contains(err.Error(), "no entropy") {
+		t.Errorf("Expected id generation error but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || !strings.Contains(err.Error(), "no entropy") {
+		t.Errorf("Expected id generation error but was %v", err)
+	}
+	coas, err := r.AllChartsOfAccounts()
+	check(t, err)
+	if len(coas) != 1 {
+		t.Errorf("Expected 1 chart but was %v", len(coas))
+	}
+	if _, ok := s["accounts/"+coa.Id]; ok {
+		t.Error("Expected no accounts to be written")
+	}
+}
+
+func TestDeterministicAccountIDs(t *testing.T) {
+	r := NewCoaRepository(store{})
+	r.IDGenerator = DeterministicIDGenerator{Namespace: uuid.NamespaceURL}
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if u, err := uuid.FromString(coa.Id); err != nil || u.Version() != uuid.V4 {
+		t.Errorf("Expected a version 4 chart id but was %v", coa.Id)
+	}
+	var ids []string
+	for i := 0; i < 2; i++ {
+		a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a11 " + strconv.Itoa(i), Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		ids = append(ids, a1.Id, a11.Id)
+	}
+	if ids[0] != ids[2] || ids[1] != ids[3] || ids[0] != uuid.NewV5(uuid.NamespaceURL, "1").String() {
+		t.Errorf("Expected the same ids on both imports but was %v", ids)
+	}
+	aa, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(aa) != 2 || aa[1].Name != "a11 1" {
+		t.Errorf("Expected 2 accounts, the second updated, but was %v", aa)
+	}
+	if _, err := r.CreateAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}}); err == nil {
+		t.Error("Expected CreateAccount to reject an existing derived id")
+	}
+	_, err = r.RenumberAccount(coa.Id, ids[0], "2")
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil {
+		t.Error("Expected an error reusing the id of a renumbered account")
+	}
+}
@@ -0,0 +1,312 @@
+package coa
+
+import (
+	"sort"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+// accountIndex lists the ids and numbers of a chart's accounts in the
+// per-account layout, sorted by number, so that number uniqueness is checked
+// without reading every account.
+type accountIndex []accountIndexEntry
+
+type accountIndexEntry struct {
+	Id     string
+	Number string
+}
+
+// MarshalMsg encodes each entry as an array of its id and number.
+func (index accountIndex) MarshalMsg(b []byte) ([]byte, error) {
+	o := msgp.AppendArrayHeader(b, uint32(len(index)))
+	for _, e := range index {
+		o = msgp.AppendArrayHeader(o, 2)
+		o = msgp.AppendString(o, e.Id)
+		o = msgp.AppendString(o, e.Number)
+	}
+	return o, nil
+}
+
+// UnmarshalMsg also reads indexes written before they held numbers, whose
+// entries are bare ids.
+func (index *accountIndex) UnmarshalMsg(bts []byte) ([]byte, error) {
+	n, bts, err := msgp.ReadArrayHeaderBytes(bts)
+	if err != nil {
+		return nil, err
+	}
+	*index = make(accountIndex, n)
+	for i := range *index {
+		e := &(*index)[i]
+		if msgp.NextType(bts) == msgp.StrType {
+			e.Id, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		size, rest, err := msgp.ReadArrayHeaderBytes(bts)
+		if err != nil {
+			return nil, err
+		}
+		if size != 2 {
+			return nil, msgp.ArrayError{Wanted: 2, Got: size}
+		}
+		if e.Id, rest, err = msgp.ReadStringBytes(rest); err != nil {
+			return nil, err
+		}
+		if e.Number, bts, err = msgp.ReadStringBytes(rest); err != nil {
+			return nil, err
+		}
+	}
+	return bts, nil
+}
+
+// hasNumbers reports whether every entry holds its number, which entries
+// of indexes written before they held numbers don't.
+func (index accountIndex) hasNumbers() bool {
+	for _, e := range index {
+		if e.Number == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func accountKey(coaid, id string) string { return "accounts/" + coaid + "/" + id }
+
+func accountIndexKey(coaid string) string { return "account-index/" + coaid }
+
+func (r *CoaRepository) loadAccounts(coaid string) (Accounts, error) {
+	if !r.PerAccount {
+		return r.loadAccountList(coaid)
+	}
+	return r.loadAccountsPerAccount(coaid)
+}
+
+func (r *CoaRepository) loadAccountList(coaid string) (Accounts, error) {
+	var accounts Accounts
+	if err := r.get("accounts/"+coaid, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *CoaRepository) loadAccountIndex(coaid string) (accountIndex, error) {
+	var index accountIndex
+	if err := r.get(accountIndexKey(coaid), &index); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+func (r *CoaRepository) loadAccountsPerAccount(coaid string) (Accounts, error) {
+	var accounts Accounts
+	index, err := r.loadAccountIndex(coaid)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range index {
+		a, err := r.loadAccount(coaid, e.Id)
+		if err != nil {
+			return nil, err
+		}
+		if a != nil {
+			accounts = append(accounts, a)
+		}
+	}
+	return accounts, nil
+}
+
+func (r *CoaRepository) loadAccount(coaid, id string) (*Account, error) {
+	a := &Account{}
+	if err := r.get(accountKey(coaid, id), a); err != nil {
+		return nil, err
+	}
+	if a.Id == "" {
+		return nil, nil
+	}
+	return a, nil
+}
+
+func (r *CoaRepository) storeAccounts(coaid string, accounts Accounts) error {
+	if !r.PerAccount {
+		return r.put("accounts/"+coaid, accounts)
+	}
+	for _, a := range accounts {
+		if err := r.put(accountKey(coaid, a.Id), a); err != nil {
+			return err
+		}
+	}
+	return r.putAccountIndex(coaid, accounts)
+}
+
+// storeAccount stores account, one of accounts. In the per-account layout
+// accounts isn't used: only the account itself is written, plus the index
+// when the account is new to it or its number changed.
+func (r *CoaRepository) storeAccount(coaid string, accounts Accounts, account *Account, created bool) error {
+	_, err := r.storeChangedAccount(coaid, accounts, account, created)
+	return err
+}
+
+// storeChangedAccount is like storeAccount but also reports whether the
+// account was written, which it isn't when SkipUnchangedWrites finds it
+// unchanged.
+func (r *CoaRepository) storeChangedAccount(coaid string, accounts Accounts, account *Account, created bool) (bool, error) {
+	if !r.PerAccount {
+		return r.putChanged("accounts/"+coaid, accounts)
+	}
+	written, err := r.putChanged(accountKey(coaid, account.Id), account)
+	if err != nil {
+		return false, err
+	}
+	return written, r.indexAccount(coaid, account)
+}
+
+// indexAccount adds the account to the index of the per-account layout, or
+// updates its number there, unless the index already has it.
+func (r *CoaRepository) indexAccount(coaid string, account *Account) error {
+	index, err := r.loadAccountIndex(coaid)
+	if err != nil {
+		return err
+	}
+	i := 0
+	for i < len(index) && index[i].Id != account.Id {
+		i++
+	}
+	if i < len(index) && index[i].Number == account.Number {
+		return nil
+	}
+	if i == len(index) {
+		index = append(index, accountIndexEntry{})
+	}
+	index[i] = accountIndexEntry{Id: account.Id, Number: account.Number}
+	index.sortByNumber(r.Separator)
+	return r.put(accountIndexKey(coaid), index)
+}
+
+func (r *CoaRepository) putAccountIndex(coaid string, accounts Accounts) error {
+	index := make(accountIndex, len(accounts))
+	for i, a := range accounts {
+		index[i] = accountIndexEntry{Id: a.Id, Number: a.Number}
+	}
+	index.sortByNumber(r.Separator)
+	return r.put(accountIndexKey(coaid), index)
+}
+
+func (index accountIndex) sortByNumber(sep string) {
+	sort.SliceStable(index, func(i, j int) bool {
+		if c := compareNumbers(index[i].Number, index[j].Number, sep); c != 0 {
+			return c < 0
+		}
+		return index[i].Id < index[j].Id
+	})
+}
+
+// numberInUse reports whether an account of the chart other than account
+// has its number. In the per-account layout the numbers are read from the
+// index; otherwise, and for indexes written before they held numbers, from
+// the accounts, which in the list layout are the ones given.
+func (r *CoaRepository) numberInUse(coaid string, account *Account, accounts Accounts) (bool, error) {
+	if r.PerAccount {
+		index, err := r.loadAccountIndex(coaid)
+		if err != nil {
+			return false, err
+		}
+		if index.hasNumbers() {
+			for _, e := range index {
+				if e.Number == account.Number && e.Id != account.Id {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+		if accounts, err = r.loadAccountsPerAccount(coaid); err != nil {
+			return false, err
+		}
+	}
+	for _, a := range accounts {
+		if a.Number == account.Number && a.Id != account.Id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// MigrateToPerAccount copies the accounts of a chart from the single list
+// layout into the per-account layout. The copy in the list replaces an
+// account already present in the per-account layout, and other accounts
+// there are kept, so running it again only repeats the copy. When
+// DeleteAfterMigration is set the list is deleted afterwards.
+func (r *CoaRepository) MigrateToPerAccount(coaid string) error {
+	legacy, err := r.loadAccountList(coaid)
+	if err != nil {
+		return err
+	}
+	if len(legacy) == 0 {
+		return nil
+	}
+	accounts, err := r.loadAccountsPerAccount(coaid)
+	if err != nil {
+		return err
+	}
+	for _, a := range legacy {
+		if err := r.put(accountKey(coaid, a.Id), a); err != nil {
+			return err
+		}
+	}
+	if err := r.putAccountIndex(coaid, mergeAccounts(accounts, legacy)); err != nil {
+		return err
+	}
+	if r.DeleteAfterMigration {
+		return r.delete("accounts/" + coaid)
+	}
+	return nil
+}
+
+// MigrateToAccountList is the reverse of MigrateToPerAccount: it copies the
+// accounts of a chart from the per-account layout back into a single list,
+// replacing the copies the list still holds from before the migration.
+func (r *CoaRepository) MigrateToAccountList(coaid string) error {
+	perAccount, err := r.loadAccountsPerAccount(coaid)
+	if err != nil {
+		return err
+	}
+	if len(perAccount) == 0 {
+		return nil
+	}
+	accounts, err := r.loadAccountList(coaid)
+	if err != nil {
+		return err
+	}
+	if err := r.put("accounts/"+coaid, mergeAccounts(accounts, perAccount)); err != nil {
+		return err
+	}
+	if r.DeleteAfterMigration {
+		for _, a := range perAccount {
+			if err := r.delete(accountKey(coaid, a.Id)); err != nil {
+				return err
+			}
+		}
+		return r.delete(accountIndexKey(coaid))
+	}
+	return nil
+}
+
+// mergeAccounts returns the accounts of to with those of from added, the
+// ones of from replacing the ones of to with the same id.
+func mergeAccounts(to, from Accounts) Accounts {
+	byId := map[string]int{}
+	result := append(Accounts(nil), to...)
+	for i, a := range result {
+		byId[a.Id] = i
+	}
+	for _, a := range from {
+		if i, ok := byId[a.Id]; ok {
+			result[i] = a
+		} else {
+			byId[a.Id] = len(result)
+			result = append(result, a)
+		}
+	}
+	return result
+}
@@ -0,0 +1,178 @@
+package coa
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestPerAccountLayout(t *testing.T) {
+	s := store{}
+	r := NewCoaRepositoryPerAccount(s)
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if _, ok := s["accounts/"+coa.Id]; ok {
+		t.Error("Expected no account list to be stored")
+	}
+	if _, ok := s["accounts/"+coa.Id+"/"+a11.Id]; !ok {
+		t.Error("Expected account to be stored under its own key")
+	}
+	a, err := r.GetAccount(coa.Id, a1.Id)
+	check(t, err)
+	if a == nil || !a.IsSummary() {
+		t.Errorf("Expected a1 to be a summary account but was %v", a)
+	}
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 2 || accounts[0].Id != a1.Id || accounts[1].Id != a11.Id {
+		t.Errorf("Expected a1 and a1.1 but was %v", accounts)
+	}
+	n, err := r.CountAccounts(coa.Id)
+	check(t, err)
+	if n != 2 {
+		t.Errorf("Expected 2 but was %v", n)
+	}
+}
+
+func TestMigrateToPerAccount(t *testing.T) {
+	s := store{}
+	legacy := NewCoaRepository(s)
+	coa, err := legacy.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := legacy.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	check(t, legacy.MigrateToPerAccount(coa.Id))
+	r := NewCoaRepositoryPerAccount(s)
+	a, err := r.GetAccount(coa.Id, a1.Id)
+	check(t, err)
+	if a == nil || a.Number != "1" {
+		t.Errorf("Expected migrated a1 but was %v", a)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 2 {
+		t.Errorf("Expected 2 accounts but was %v", len(accounts))
+	}
+}
+
+func TestMigrationsAreIdempotentAndReversible(t *testing.T) {
+	s := store{}
+	legacy := NewCoaRepository(s)
+	coa, err := legacy.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = legacy.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	check(t, legacy.MigrateToPerAccount(coa.Id))
+	r := NewCoaRepositoryPerAccount(s)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	check(t, legacy.MigrateToPerAccount(coa.Id))
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 2 {
+		t.Errorf("Expected re-running the migration to keep 2 accounts but was %v", len(accounts))
+	}
+
+	r.DeleteAfterMigration = true
+	check(t, r.MigrateToAccountList(coa.Id))
+	accounts, err = legacy.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 2 {
+		t.Errorf("Expected 2 accounts after rollback but was %v", len(accounts))
+	}
+	accounts, err = r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 0 {
+		t.Errorf("Expected per-account layout to be deleted but was %v", len(accounts))
+	}
+	check(t, r.MigrateToAccountList(coa.Id))
+	accounts, err = legacy.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 2 {
+		t.Errorf("Expected re-running the rollback to keep 2 accounts but was %v", len(accounts))
+	}
+}
+
+func TestRollbackKeepsEditsMadeAfterMigration(t *testing.T) {
+	s := store{}
+	legacy := NewCoaRepository(s)
+	coa, err := legacy.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := legacy.SaveAccount(coa.Id, &Account{Number: "1", Name: "old", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	check(t, legacy.MigrateToPerAccount(coa.Id))
+	r := NewCoaRepositoryPerAccount(s)
+	_, err = r.SaveAccount(coa.Id, &Account{Id: a1.Id, Number: "1", Name: "new", Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}})
+	check(t, err)
+	check(t, r.MigrateToAccountList(coa.Id))
+	a, err := legacy.GetAccount(coa.Id, a1.Id)
+	check(t, err)
+	if a == nil || a.Name != "new" {
+		t.Errorf("Expected the edit made after the migration but was %v", a)
+	}
+	accounts, err := legacy.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 1 {
+		t.Errorf("Expected 1 account but was %v", accounts)
+	}
+	_, err = legacy.SaveAccount(coa.Id, &Account{Id: a1.Id, Number: "1", Name: "newer", Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}})
+	check(t, err)
+	check(t, legacy.MigrateToPerAccount(coa.Id))
+	a, err = r.GetAccount(coa.Id, a1.Id)
+	check(t, err)
+	if a == nil || a.Name != "newer" {
+		t.Errorf("Expected the list's copy to win but was %v", a)
+	}
+}
+
+func TestPerAccountSaveReadsFewKeys(t *testing.T) {
+	r := NewCoaRepositoryPerAccount(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	for i := 1; i <= 50; i++ {
+		_, err := r.SaveAccount(coa.Id, &Account{Number: "1." + strconv.Itoa(i), Name: "a", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+	}
+	o := &countingObserver{failures: map[string]int{}}
+	r.Observer = o
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1.51", Name: "a", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if o.gets > 10 {
+		t.Errorf("Expected the save not to read every account but was %v gets", o.gets)
+	}
+	if _, err := r.SaveAccount(coa.Id, &Account{Number: "1.51", Name: "b", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}}); err == nil {
+		t.Error("Expected a repeated number to be rejected")
+	}
+	_, err = r.RenumberAccount(coa.Id, a.Id, "1.52")
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.51", Name: "b", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+}
+
+func TestLegacyAccountIndex(t *testing.T) {
+	r := NewCoaRepositoryPerAccount(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	o := msgp.AppendArrayHeader(nil, 1)
+	o = msgp.AppendString(o, a1.Id)
+	check(t, r.putRaw(accountIndexKey(coa.Id), o))
+	aa, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(aa) != 1 || aa[0].Id != a1.Id {
+		t.Errorf("Expected %v but was %v", a1, aa)
+	}
+	if _, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "b", Tags: []string{"balanceSheet", "increaseOnDebit"}}); err == nil {
+		t.Error("Expected a repeated number to be rejected")
+	}
+}
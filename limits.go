@@ -0,0 +1,85 @@
+package coa
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Default limits set by the constructors; long enough for any sensible
+// chart.
+const (
+	DefaultMaxChartNameLength     = 256
+	DefaultMaxAccountNameLength   = 256
+	DefaultMaxAccountNumberLength = 64
+)
+
+// NonPrintable tells whether c isn't printable, such as control characters,
+// newlines and null bytes. Unlike unicode.IsPrint, it accepts every space
+// character, such as the no-break and ideographic spaces.
+func NonPrintable(c rune) bool { return !unicode.IsGraphic(c) }
+
+func (r *CoaRepository) chartLimitsMessage(coa *ChartOfAccounts) string {
+	if msg := maxLengthMessage("name", coa.Name, r.MaxChartNameLength); msg != "" {
+		return msg
+	}
+	if msg := r.disallowedRuneMessage("name", coa.Name); msg != "" {
+		return msg
+	}
+	if i := strings.IndexAny(coa.Name, r.ChartNameDisallowedChars); r.ChartNameDisallowedChars != "" && i != -1 {
+		c, _ := utf8.DecodeRuneInString(coa.Name[i:])
+		return "The name must not contain " + strconv.QuoteRune(c)
+	}
+	return ""
+}
+
+func (r *CoaRepository) accountLimitsMessage(account *Account) string {
+	if msg := maxLengthMessage("number", account.Number, r.MaxAccountNumberLength); msg != "" {
+		return msg
+	}
+	if msg := maxLengthMessage("name", account.Name, r.MaxAccountNameLength); msg != "" {
+		return msg
+	}
+	if msg := r.disallowedRuneMessage("number", account.Number); msg != "" {
+		return msg
+	}
+	return r.disallowedRuneMessage("name", account.Name)
+}
+
+func (r *CoaRepository) disallowedRuneMessage(field, value string) string {
+	if r.DisallowedRune == nil {
+		return ""
+	}
+	for _, c := range value {
+		if r.DisallowedRune(c) {
+			return "The " + field + " must not contain " + strconv.QuoteRune(c)
+		}
+	}
+	return ""
+}
+
+// maxLengthMessage counts characters, not bytes. A max of zero means no
+// limit.
+func maxLengthMessage(field, value string, max int) string {
+	if max > 0 && utf8.RuneCountInString(value) > max {
+		return "The " + field + " must have at most " + strconv.Itoa(max) + " characters"
+	}
+	return ""
+}
+
+// checkAccountLimit fails when adding n accounts would exceed
+// MaxAccountsPerChart.
+func (r *CoaRepository) checkAccountLimit(coaid string, n int) error {
+	if r.MaxAccountsPerChart <= 0 {
+		return nil
+	}
+	count, err := r.CountAccounts(coaid)
+	if err != nil {
+		return err
+	}
+	if count+n > r.MaxAccountsPerChart {
+		return ValidationError("The chart of accounts has " + strconv.Itoa(count) + " accounts and can't have more than " + strconv.Itoa(r.MaxAccountsPerChart))
+	}
+	return nil
+}
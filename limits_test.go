@@ -0,0 +1,81 @@
+package coa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	r := NewCoaRepository(store{})
+	_, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: strings.Repeat("a", DefaultMaxChartNameLength+1)})
+	if err == nil || err.Error() != "The name must have at most 256 characters" {
+		t.Errorf("Expected a length error but was %v", err)
+	}
+	r.ChartNameDisallowedChars = "/\\"
+	_, err = r.SaveChartOfAccounts(&ChartOfAccounts{Name: "a/b"})
+	if err == nil || err.Error() != "The name must not contain '/'" {
+		t.Errorf("Expected a character error but was %v", err)
+	}
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	r.MaxAccountNumberLength = 3
+	r.MaxAccountNameLength = 4
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "a", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The number must have at most 3 characters" {
+		t.Errorf("Expected a number length error but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "caixa", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The name must have at most 4 characters" {
+		t.Errorf("Expected a name length error but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "ação", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	r.MaxAccountNameLength = 0
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: strings.Repeat("a", 1000), Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+}
+
+func TestMaxAccountsPerChart(t *testing.T) {
+	r := NewCoaRepository(store{})
+	r.MaxAccountsPerChart = 2
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The chart of accounts has 2 accounts and can't have more than 2" {
+		t.Errorf("Expected a limit error but was %v", err)
+	}
+	a1.Name = "renamed"
+	_, err = r.SaveAccount(coa.Id, a1)
+	check(t, err)
+}
+
+func TestDisallowedRune(t *testing.T) {
+	r := NewCoaRepository(store{})
+	_, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "a\nb"})
+	if err == nil || err.Error() != `The name must not contain '\n'` {
+		t.Errorf("Expected a character error but was %v", err)
+	}
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1\x00", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != `The number must not contain '\x00'` {
+		t.Errorf("Expected a character error but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "Cash\r\nand banks", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != `The name must not contain '\r'` {
+		t.Errorf("Expected a character error but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "9", Name: "Caixa\u00a0e\u3000bancos", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	r.DisallowedRune = func(c rune) bool { return c == '|' }
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "Cash|banks", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The name must not contain '|'" {
+		t.Errorf("Expected a character error but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "Cash\nand banks", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+}
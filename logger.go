@@ -0,0 +1,39 @@
+package coa
+
+// Logger receives an event for every chart or account written by a
+// CoaRepository, so that operators can ship mutations to their own logging
+// system. Like the change feed, raw imports and layout migrations are not
+// logged.
+//
+// The events are "chart.created", "chart.updated", "account.created",
+// "account.updated", "account.deleted" and "account.restored". Fields always
+// hold "id" and "created"; account events also hold "coaid".
+type Logger interface {
+	Log(event string, fields map[string]interface{})
+}
+
+func (r *CoaRepository) logChart(coa *ChartOfAccounts, created bool) {
+	if r.Logger == nil {
+		return
+	}
+	event := "chart.updated"
+	if created {
+		event = "chart.created"
+	}
+	r.Logger.Log(event, map[string]interface{}{"id": coa.Id, "created": created})
+}
+
+func (r *CoaRepository) logAccount(coaid string, account *Account, created bool) {
+	event := "account.updated"
+	if created {
+		event = "account.created"
+	}
+	r.logAccountEvent(event, coaid, account, created)
+}
+
+func (r *CoaRepository) logAccountEvent(event, coaid string, account *Account, created bool) {
+	if r.Logger == nil {
+		return
+	}
+	r.Logger.Log(event, map[string]interface{}{"id": account.Id, "coaid": coaid, "created": created})
+}
@@ -0,0 +1,76 @@
+package coa
+
+import "testing"
+
+type recordingLogger struct {
+	events []string
+	fields []map[string]interface{}
+}
+
+func (l *recordingLogger) Log(event string, fields map[string]interface{}) {
+	l.events = append(l.events, event)
+	l.fields = append(l.fields, fields)
+}
+
+func TestLogger(t *testing.T) {
+	r := NewCoaRepository(store{})
+	l := &recordingLogger{}
+	r.Logger = l
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveChartOfAccounts(coa)
+	check(t, err)
+	expected := []string{"chart.created", "account.created", "account.created", "account.updated", "chart.updated"}
+	if len(l.events) != len(expected) {
+		t.Fatalf("Expected %v but was %v", expected, l.events)
+	}
+	for i, e := range expected {
+		if l.events[i] != e {
+			t.Errorf("Expected %v but was %v", e, l.events[i])
+		}
+	}
+	if l.fields[3]["id"] != a1.Id || l.fields[3]["coaid"] != coa.Id || l.fields[3]["created"] != false {
+		t.Errorf("Expected the update of %v but was %v", a1.Id, l.fields[3])
+	}
+}
+
+func TestLoggerBulkWrites(t *testing.T) {
+	r := NewCoaRepository(store{})
+	l := &recordingLogger{}
+	r.Logger = l
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	logged := len(l.events)
+	_, err = r.RenumberAccount(coa.Id, a1.Id, "2")
+	check(t, err)
+	if len(l.events) != logged+2 || l.fields[logged+1]["id"] != a11.Id {
+		t.Errorf("Expected the renumbered account and its child logged but was %v", l.fields[logged:])
+	}
+	logged = len(l.events)
+	check(t, r.RebuildNumbers(coa.Id, 1))
+	if len(l.events) != logged+2 {
+		t.Errorf("Expected 2 accounts logged but was %v", l.fields[logged:])
+	}
+	aa, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	for _, a := range aa {
+		if a.Id == a11.Id {
+			a.Tags = []string{"balanceSheet", "increaseOnDebit", "summary"}
+		}
+	}
+	check(t, r.put("accounts/"+coa.Id, aa))
+	logged = len(l.events)
+	_, err = r.Repair(coa.Id, RepairOptions{FixDetailSummary: true})
+	check(t, err)
+	if len(l.events) != logged+1 || l.fields[logged]["id"] != a11.Id {
+		t.Errorf("Expected the repaired account logged but was %v", l.fields[logged:])
+	}
+}
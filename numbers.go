@@ -0,0 +1,307 @@
+package coa
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func (r *CoaRepository) RebuildNumbers(coaid string, rootStart int) error {
+	aa, numbers, err := r.rebuiltNumbers(coaid, rootStart)
+	if err != nil {
+		return r.observeFailure("RebuildNumbers", err)
+	}
+	var changed Accounts
+	for _, a := range aa {
+		if n, ok := numbers[a.Id]; ok && n != a.Number {
+			a.Number = n
+			a.AsOf = r.Clock.Now()
+			changed = append(changed, a)
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+	if err := r.storeAccounts(coaid, aa); err != nil {
+		return r.observeFailure("RebuildNumbers", err)
+	}
+	for _, a := range changed {
+		r.logAccount(coaid, a, false)
+	}
+	r.publishAccounts(coaid, UpdateOperation, changed...)
+	return nil
+}
+
+// ProposedNumbers is the dry run of RebuildNumbers: it returns the numbers
+// that would change, mapping the id of each account to its new number.
+func (r *CoaRepository) ProposedNumbers(coaid string, rootStart int) (map[string]string, error) {
+	aa, numbers, err := r.rebuiltNumbers(coaid, rootStart)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]string{}
+	for _, a := range aa {
+		if n, ok := numbers[a.Id]; ok && n != a.Number {
+			result[a.Id] = n
+		}
+	}
+	return result, nil
+}
+
+// rebuiltNumbers returns the accounts of the chart, which must exist, and
+// the numbers rebuildNumbers assigns them.
+func (r *CoaRepository) rebuiltNumbers(coaid string, rootStart int) (Accounts, map[string]string, error) {
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return nil, nil, err
+	}
+	if coa == nil {
+		return nil, nil, fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, nil, err
+	}
+	return aa, rebuildNumbers(aa, rootStart, r.Separator), nil
+}
+
+// rebuildNumbers assigns dotted numbers top-down, keeping the current order
+// of siblings. Accounts with a dangling parent are numbered as roots and
+// accounts that can't be reached from a root (cycles) are left out.
+func rebuildNumbers(aa Accounts, rootStart int, sep string) map[string]string {
+	ids := map[string]bool{}
+	for _, a := range aa {
+		ids[a.Id] = true
+	}
+	children := map[string]Accounts{}
+	for _, a := range aa {
+		parent := a.Parent
+		if !ids[parent] {
+			parent = ""
+		}
+		children[parent] = append(children[parent], a)
+	}
+	result := map[string]string{}
+	var walk func(parent, prefix string, start int)
+	walk = func(parent, prefix string, start int) {
+		for i, a := range children[parent] {
+			if _, ok := result[a.Id]; ok {
+				continue
+			}
+			n := prefix + strconv.Itoa(start+i)
+			result[a.Id] = n
+			walk(a.Id, n+sep, 1)
+		}
+	}
+	walk("", "", rootStart)
+	return result
+}
+
+// hasNumberPrefix reports whether number is prefix itself or one of its
+// descendants, i.e. the rest of number after prefix starts with sep.
+func hasNumberPrefix(number, prefix, sep string) bool {
+	if !strings.HasPrefix(number, prefix) {
+		return false
+	}
+	rest := number[len(prefix):]
+	return rest == "" || strings.HasPrefix(rest, sep)
+}
+
+// separatorMessage reports a number containing one of separators other
+// than sep.
+func separatorMessage(number, sep, separators string) string {
+	for _, c := range number {
+		if strings.ContainsRune(separators, c) && !strings.ContainsRune(sep, c) {
+			return "The number must use " + strconv.Quote(sep) + " as separator, not " + strconv.QuoteRune(c)
+		}
+	}
+	return ""
+}
+
+// RenumberAccount changes the number of an account, which SaveAccount keeps
+// unchanged on updates. The new number must be unique and start with the
+// parent's number. The descendants whose numbers start with the old number
+// get the new number as their prefix instead.
+func (r *CoaRepository) RenumberAccount(coaid, id, number string) (*Account, error) {
+	result, err := r.renumberAccount(coaid, id, number)
+	if err != nil {
+		return nil, r.observeFailure("RenumberAccount", err)
+	}
+	return result.Primary, nil
+}
+
+// RenumberAccountWithResult is like RenumberAccount but also returns the
+// descendants whose numbers changed.
+func (r *CoaRepository) RenumberAccountWithResult(coaid, id, number string) (*MutationResult, error) {
+	result, err := r.renumberAccount(coaid, id, number)
+	if err != nil {
+		return nil, r.observeFailure("RenumberAccount", err)
+	}
+	return result, nil
+}
+
+func (r *CoaRepository) renumberAccount(coaid, id, number string) (*MutationResult, error) {
+	if coaid == "" {
+		return nil, fmt.Errorf("Invalid argument: coaid is empty")
+	}
+	accounts, err := r.loadAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	var account *Account
+	for _, a := range accounts {
+		if a.Id == id {
+			account = a
+			break
+		}
+	}
+	if account == nil {
+		return nil, fmt.Errorf("Account %v %w", id, ErrNotFound)
+	}
+	if !account.Removed.IsZero() {
+		return nil, fmt.Errorf("Account %v %w", id, ErrRemoved)
+	}
+	number = r.normalizeSpace(number)
+	if account.Number == number {
+		return &MutationResult{Primary: account}, nil
+	}
+	old := account.Number
+	account.Number = number
+	if msg := account.ValidationMessage(coaid, r); msg != "" {
+		return nil, ValidationError(msg)
+	}
+	now := r.Clock.Now()
+	account.AsOf = now
+	descendants := descendantsOf(accounts, id)
+	result := &MutationResult{Primary: account}
+	for _, d := range descendants {
+		if hasNumberPrefix(d.Number, old, r.Separator) {
+			d.Number = number + d.Number[len(old):]
+			d.AsOf = now
+			result.AlsoChanged = append(result.AlsoChanged, d)
+		}
+	}
+	if issues := subtreeNumberIssues(accounts, id, r.Separator); len(issues) > 0 {
+		return nil, ValidationError(issues[0].Message)
+	}
+	if len(descendants) == 0 {
+		err = r.storeAccount(coaid, accounts, account, false)
+	} else {
+		err = r.storeAccounts(coaid, accounts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.logAccount(coaid, account, false)
+	for _, d := range result.AlsoChanged {
+		r.logAccount(coaid, d, false)
+	}
+	r.publishAccounts(coaid, UpdateOperation, account)
+	r.publishAccounts(coaid, UpdateOperation, result.AlsoChanged...)
+	return result, nil
+}
+
+// subtreeNumberIssues checks that every account below rootId starts with
+// its parent's number and that no account of the subtree shares its number
+// with another account of the chart.
+func subtreeNumberIssues(accounts Accounts, rootId, sep string) []ValidationIssue {
+	numbers := map[string]int{}
+	var root *Account
+	for _, a := range accounts {
+		numbers[a.Number]++
+		if a.Id == rootId {
+			root = a
+		}
+	}
+	if root == nil {
+		return []ValidationIssue{{AccountId: rootId, Message: "Account not found: " + rootId}}
+	}
+	byId := map[string]*Account{}
+	for _, a := range accounts {
+		byId[a.Id] = a
+	}
+	var issues []ValidationIssue
+	for _, a := range append(Accounts{root}, descendantsOf(accounts, rootId)...) {
+		if numbers[a.Number] > 1 {
+			issues = append(issues, ValidationIssue{AccountId: a.Id, Message: "An account with this number already exists"})
+		} else if a != root && !hasNumberPrefix(a.Number, byId[a.Parent].Number, sep) {
+			issues = append(issues, ValidationIssue{AccountId: a.Id, Message: "The number must start with parent's number"})
+		}
+	}
+	return issues
+}
+
+// descendantsOf returns the accounts below id, parents before children.
+func descendantsOf(accounts Accounts, id string) Accounts {
+	children := map[string]Accounts{}
+	for _, a := range accounts {
+		children[a.Parent] = append(children[a.Parent], a)
+	}
+	var result Accounts
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		for _, c := range children[queue[0]] {
+			if !visited[c.Id] {
+				visited[c.Id] = true
+				result = append(result, c)
+				queue = append(queue, c.Id)
+			}
+		}
+		queue = queue[1:]
+	}
+	return result
+}
+
+// NumberGaps returns the numbers missing between 1 and the highest numeric
+// last segment of the children of parentId, or of the root accounts when
+// parentId is empty. Children whose last segment isn't numeric are ignored,
+// and removed children still take their number.
+func (r *CoaRepository) NumberGaps(coaid, parentId string) ([]string, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	prefix := ""
+	if parentId != "" {
+		var parent *Account
+		for _, a := range aa {
+			if a.Id == parentId {
+				parent = a
+			}
+		}
+		if parent == nil {
+			return nil, fmt.Errorf("Account %v %w", parentId, ErrNotFound)
+		}
+		prefix = parent.Number + r.Separator
+	}
+	taken := map[int]bool{}
+	max, width := 0, 0
+	for _, a := range aa {
+		if a.Parent != parentId || !strings.HasPrefix(a.Number, prefix) {
+			continue
+		}
+		segment := a.Number[len(prefix):]
+		if !isDigits(segment) {
+			continue
+		}
+		n, err := strconv.Atoi(segment)
+		if err != nil {
+			continue
+		}
+		taken[n] = true
+		if n > max {
+			max = n
+		}
+		if strings.HasPrefix(segment, "0") && len(segment) > width {
+			width = len(segment)
+		}
+	}
+	result := []string{}
+	for n := 1; n < max; n++ {
+		if !taken[n] {
+			result = append(result, fmt.Sprintf("%v%0*d", prefix, width, n))
+		}
+	}
+	return result, nil
+}
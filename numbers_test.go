@@ -0,0 +1,265 @@
+package coa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRebuildNumbers(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.2", Name: "a1.2", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	for i, n := range []string{"3", "3.5", "38", "7"} {
+		accounts[i].Number = n
+	}
+	check(t, r.put("accounts/"+coa.Id, accounts))
+
+	proposed, err := r.ProposedNumbers(coa.Id, 1)
+	check(t, err)
+	expected := map[string]string{}
+	for _, a := range accounts {
+		expected[a.Id] = map[string]string{"a1": "1", "a1.1": "1.1", "a1.2": "1.2", "a2": "2"}[a.Name]
+	}
+	if len(proposed) != len(expected) {
+		t.Errorf("Expected %v but was %v", expected, proposed)
+	}
+	for k, v := range expected {
+		if proposed[k] != v {
+			t.Errorf("Expected %v -> %v but was %v", k, v, proposed[k])
+		}
+	}
+	accounts, err = r.AllAccounts(coa.Id)
+	check(t, err)
+	if accounts[0].Number != "3" {
+		t.Errorf("Expected dry run not to write but was %v", accounts[0].Number)
+	}
+
+	check(t, r.RebuildNumbers(coa.Id, 1))
+	accounts, err = r.AllAccounts(coa.Id)
+	check(t, err)
+	for i, n := range []string{"1", "1.1", "1.2", "2"} {
+		if accounts[i].Number != n {
+			t.Errorf("Expected accounts[%v].Number==%v but was %v", i, n, accounts[i].Number)
+		}
+	}
+
+	accounts[3].Number = "1"
+	check(t, r.put("accounts/"+coa.Id, accounts))
+	proposed, err = r.ProposedNumbers(coa.Id, 5)
+	check(t, err)
+	if len(proposed) != 4 {
+		t.Errorf("Expected both accounts numbered 1 to be proposed but was %v", proposed)
+	}
+}
+
+func TestRebuildNumbersOfUnknownChart(t *testing.T) {
+	s := store{}
+	r := NewCoaRepository(s)
+	if err := r.RebuildNumbers("bogus", 1); err == nil {
+		t.Error("Expected an unknown chart to be rejected")
+	}
+	if _, err := r.ProposedNumbers("bogus", 1); err == nil {
+		t.Error("Expected an unknown chart to be rejected")
+	}
+	if len(s) != 0 {
+		t.Errorf("Expected nothing written but was %v", s)
+	}
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	check(t, r.RebuildNumbers(coa.Id, 1))
+	if _, ok := s["accounts/"+coa.Id]; ok {
+		t.Error("Expected no write when no number changes")
+	}
+}
+
+func TestHasNumberPrefix(t *testing.T) {
+	tests := []struct {
+		number, prefix, sep string
+		expected            bool
+	}{
+		{"1", "1", ".", true},
+		{"1.0", "1", ".", true},
+		{"1.1.1", "1.1", ".", true},
+		{"10", "1", ".", false},
+		{"111", "11", ".", false},
+		{"1", "10", ".", false},
+		{"1-2", "1", "-", true},
+		{"10", "1", "", true},
+	}
+	for _, test := range tests {
+		if got := hasNumberPrefix(test.number, test.prefix, test.sep); got != test.expected {
+			t.Errorf("Expected hasNumberPrefix(%q, %q, %q)==%v but was %v", test.number, test.prefix, test.sep, test.expected, got)
+		}
+	}
+}
+
+func TestChildNumberMustHaveParentSegment(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "10", Name: "a10", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "The number must start with parent's number" {
+		t.Errorf("Expected prefix error but was %v", err)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.0", Name: "a1.0", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+}
+
+func TestRenumberAccount(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.2", Name: "a1.2", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.RenumberAccount(coa.Id, a11.Id, "1.2")
+	if err == nil || err.Error() != "An account with this number already exists" {
+		t.Errorf("Expected a duplicated number error but was %v", err)
+	}
+	_, err = r.RenumberAccount(coa.Id, a11.Id, " 1.2 ")
+	if err == nil || err.Error() != "An account with this number already exists" {
+		t.Errorf("Expected a padded number to be trimmed but was %v", err)
+	}
+	_, err = r.RenumberAccount(coa.Id, a11.Id, "2.1")
+	if err == nil || err.Error() != "The number must start with parent's number" {
+		t.Errorf("Expected a prefix error but was %v", err)
+	}
+	renumbered, err := r.RenumberAccount(coa.Id, a11.Id, "1.3")
+	check(t, err)
+	if renumbered.Number != "1.3" {
+		t.Errorf("Expected 1.3 but was %v", renumbered.Number)
+	}
+	a11, err = r.GetAccount(coa.Id, a11.Id)
+	check(t, err)
+	if a11.Number != "1.3" {
+		t.Errorf("Expected 1.3 but was %v", a11.Number)
+	}
+	_, err = r.SaveAccount(coa.Id, a11)
+	check(t, err)
+}
+
+func TestAutoPrefixNumbers(t *testing.T) {
+	r := NewCoaRepository(store{})
+	r.AutoPrefixNumbers = true
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if a11.Number != "1.1" {
+		t.Errorf("Expected 1.1 but was %v", a11.Number)
+	}
+	a113, err := r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a1.1.3", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if a113.Number != "1.1.3" {
+		t.Errorf("Expected 1.1.3 but was %v", a113.Number)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "3", Name: "dup", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != "An account with this number already exists" {
+		t.Errorf("Expected a duplicated number error but was %v", err)
+	}
+}
+
+func TestRenumberParent(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "a1.1.1", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.RenumberAccount(coa.Id, a1.Id, "3")
+	check(t, err)
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if issues := subtreeNumberIssues(accounts, a1.Id, r.Separator); len(issues) != 0 {
+		t.Errorf("Expected no issues but was %v", issues)
+	}
+	for i, n := range []string{"3", "3.1", "3.1.1"} {
+		if accounts[i].Number != n {
+			t.Errorf("Expected %v but was %v", n, accounts[i].Number)
+		}
+	}
+	grandchild := accounts[2]
+	grandchild.Number = "1.1.1"
+	issues := subtreeNumberIssues(accounts, a1.Id, r.Separator)
+	if len(issues) != 1 || issues[0].AccountId != grandchild.Id {
+		t.Errorf("Expected the grandchild to be flagged but was %v", issues)
+	}
+}
+
+func TestNumberGaps(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	for _, number := range []string{"1.1", "1.2", "1.4", "1.7"} {
+		_, err = r.SaveAccount(coa.Id, &Account{Number: number, Name: "a" + number, Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+	}
+	gaps, err := r.NumberGaps(coa.Id, a1.Id)
+	check(t, err)
+	if strings.Join(gaps, " ") != "1.3 1.5 1.6" {
+		t.Errorf("Expected 1.3 1.5 1.6 but was %v", gaps)
+	}
+	gaps, err = r.NumberGaps(coa.Id, "")
+	check(t, err)
+	if len(gaps) != 1 || gaps[0] != "2" {
+		t.Errorf("Expected 2 but was %v", gaps)
+	}
+	a3, err := r.SaveAccount(coa.Id, &Account{Number: "1.3", Name: "a1.3", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	gaps, err = r.NumberGaps(coa.Id, a3.Id)
+	check(t, err)
+	if gaps == nil || len(gaps) != 0 {
+		t.Errorf("Expected no gaps but was %v", gaps)
+	}
+	_, err = r.NumberGaps(coa.Id, "unknown")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+}
+
+func TestNumberSeparators(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1-2", Name: "a12", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	r.NumberSeparators = ".-/"
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2/1", Name: "a21", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if err == nil || err.Error() != `The number must use "." as separator, not '/'` {
+		t.Errorf("Expected a separator error but was %v", err)
+	}
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2.1", Name: "a21", Parent: a2.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	issues, err := r.Validate(coa.Id)
+	check(t, err)
+	if len(issues) != 1 || issues[0].Message != `The number must use "." as separator, not '-'` {
+		t.Errorf("Expected the legacy number flagged but was %v", issues)
+	}
+}
@@ -0,0 +1,36 @@
+package coa
+
+import "time"
+
+// Observer receives measurements from a CoaRepository, so that operators can
+// export them to a metrics system without this package depending on one.
+type Observer interface {
+	// ObserveGet is called after every read from the store.
+	ObserveGet(dur time.Duration, err error)
+	// ObservePut is called after every write to the store.
+	ObservePut(dur time.Duration, key string, err error)
+	// ObserveFailure is called when a public operation fails. The operation
+	// is the method name, such as "SaveAccount" or "ValidateChartOfAccounts".
+	// Validation failures can be told apart by checking for ValidationError.
+	ObserveFailure(operation string, err error)
+}
+
+func (r *CoaRepository) observeGet(start time.Time, err error) {
+	if r.Observer != nil {
+		r.Observer.ObserveGet(time.Since(start), err)
+	}
+}
+
+func (r *CoaRepository) observePut(start time.Time, key string, err error) {
+	if r.Observer != nil {
+		r.Observer.ObservePut(time.Since(start), key, err)
+	}
+}
+
+// observeFailure reports err, if any, and returns it unchanged.
+func (r *CoaRepository) observeFailure(operation string, err error) error {
+	if err != nil && r.Observer != nil {
+		r.Observer.ObserveFailure(operation, err)
+	}
+	return err
+}
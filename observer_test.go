@@ -0,0 +1,156 @@
+package coa
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingObserver struct {
+	gets, puts int
+	keys       []string
+	failures   map[string]int
+}
+
+func (o *countingObserver) ObserveGet(dur time.Duration, err error) { o.gets++ }
+
+func (o *countingObserver) ObservePut(dur time.Duration, key string, err error) {
+	o.puts++
+	o.keys = append(o.keys, key)
+}
+
+func (o *countingObserver) ObserveFailure(operation string, err error) {
+	o.failures[operation]++
+}
+
+func TestObserver(t *testing.T) {
+	r := NewCoaRepository(store{})
+	o := &countingObserver{failures: map[string]int{}}
+	r.Observer = o
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if o.gets != 1 || o.puts != 1 || o.keys[0] != "charts-of-accounts" {
+		t.Errorf("Expected 1 get and 1 put of charts-of-accounts but was %v %v %v", o.gets, o.puts, o.keys)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1"})
+	var validationError ValidationError
+	if !errors.As(err, &validationError) {
+		t.Errorf("Expected a validation error but was %v", err)
+	}
+	check(t, r.ValidateAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}}))
+	if o.failures["SaveAccount"] != 1 || len(o.failures) != 1 {
+		t.Errorf("Expected one SaveAccount failure but was %v", o.failures)
+	}
+	_, err = r.SaveChartOfAccounts(nil)
+	if err == nil || o.failures["SaveChartOfAccounts"] != 1 {
+		t.Errorf("Expected one SaveChartOfAccounts failure but was %v", o.failures)
+	}
+}
+
+func TestSkipUnchangedWrites(t *testing.T) {
+	r := NewCoaRepositoryWithClock(store{}, fixedClock(time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)))
+	o := &countingObserver{failures: map[string]int{}}
+	r.Observer = o
+	r.SkipUnchangedWrites = true
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	puts := o.puts
+	_, err = r.SaveAccount(coa.Id, &Account{Id: a.Id, Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}})
+	check(t, err)
+	if o.puts != puts {
+		t.Errorf("Expected no put but was %v", o.keys[puts:])
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Id: a.Id, Number: "1", Name: "renamed", Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}})
+	check(t, err)
+	if o.puts != puts+1 {
+		t.Errorf("Expected 1 put but was %v", o.keys[puts:])
+	}
+}
+
+func TestSkipUnchangedWritesIgnoresAsOf(t *testing.T) {
+	first := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	r := NewCoaRepositoryWithClock(store{}, fixedClock(first))
+	o := &countingObserver{failures: map[string]int{}}
+	r.Observer = o
+	l := &recordingLogger{}
+	r.Logger = l
+	r.SkipUnchangedWrites = true
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	events := r.Subscribe()
+	puts, logged := o.puts, len(l.events)
+	r.Clock = fixedClock(first.Add(time.Hour))
+	_, err = r.SaveAccount(coa.Id, &Account{Id: a.Id, Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}})
+	check(t, err)
+	_, err = r.SaveChartOfAccounts(coa)
+	check(t, err)
+	if o.puts != puts || len(l.events) != logged {
+		t.Errorf("Expected no put and no log but was %v and %v", o.keys[puts:], l.events[logged:])
+	}
+	select {
+	case e := <-events:
+		t.Errorf("Expected no event but was %v", e)
+	default:
+	}
+	stored, err := r.GetAccount(coa.Id, a.Id)
+	check(t, err)
+	if !stored.AsOf.Equal(first) {
+		t.Errorf("Expected %v but was %v", first, stored.AsOf)
+	}
+}
+
+func TestSaveReadsDontGrowWithDepth(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	var gets []int
+	parent, number := "", "1"
+	for depth := 0; depth <= 4; depth++ {
+		o := &countingObserver{failures: map[string]int{}}
+		r.Observer = o
+		a, err := r.SaveAccount(coa.Id, &Account{Number: number, Name: "a" + number, Parent: parent, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		gets = append(gets, o.gets)
+		parent, number = a.Id, number+".1"
+	}
+	if gets[4] != gets[2] {
+		t.Errorf("Expected as many reads at depth 4 as at depth 2 but was %v", gets)
+	}
+}
+
+func TestObserveBulkFailures(t *testing.T) {
+	r := NewCoaRepository(store{})
+	o := &countingObserver{failures: map[string]int{}}
+	r.Observer = o
+	if _, err := r.RenumberAccount("", "1", "2"); err == nil {
+		t.Error("Expected renumbering without a chart to fail")
+	}
+	if _, err := r.RenumberAccountWithResult("", "1", "2"); err == nil {
+		t.Error("Expected renumbering without a chart to fail")
+	}
+	if _, err := r.CopySubtree("", "1", "", "2"); err == nil {
+		t.Error("Expected copying without a chart to fail")
+	}
+	if _, err := r.RenameTag("", "cost", "deduction"); err == nil {
+		t.Error("Expected renaming without a chart to fail")
+	}
+	if _, err := r.Repair("", RepairOptions{FixDetailSummary: true}); err == nil {
+		t.Error("Expected repairing without a chart to fail")
+	}
+	if err := r.RebuildNumbers("", 1); err == nil {
+		t.Error("Expected rebuilding without a chart to fail")
+	}
+	expected := map[string]int{"RenumberAccount": 2, "CopySubtree": 1, "RenameTag": 1, "Repair": 1, "RebuildNumbers": 1}
+	if len(o.failures) != len(expected) {
+		t.Errorf("Expected failures %v but was %v", expected, o.failures)
+	}
+	for op, n := range expected {
+		if o.failures[op] != n {
+			t.Errorf("Expected %v %v failures but was %v", n, op, o.failures[op])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package coa
+
+import "fmt"
+
+// immutableFields are the keys PatchAccount refuses because SaveAccount
+// keeps them from the stored account.
+var immutableFields = map[string]bool{
+	"_id":     true,
+	"number":  true,
+	"parent":  true,
+	"created": true,
+}
+
+// PatchAccount changes only the fields in patch, keyed by their JSON names,
+// and saves the account like SaveAccount. Only "name", a string, and
+// "tags", a list of strings, can be patched; the number is changed with
+// RenumberAccount.
+func (r *CoaRepository) PatchAccount(coaid, id string, patch map[string]interface{}) (*Account, error) {
+	account, err := r.patchedAccount(coaid, id, patch)
+	if err != nil {
+		return nil, r.observeFailure("PatchAccount", err)
+	}
+	result, err := r.saveAccount(coaid, account, "")
+	if err != nil {
+		return nil, r.observeFailure("PatchAccount", err)
+	}
+	return result.Account, nil
+}
+
+func (r *CoaRepository) patchedAccount(coaid, id string, patch map[string]interface{}) (*Account, error) {
+	if coaid == "" {
+		return nil, fmt.Errorf("Invalid argument: coaid is empty")
+	}
+	old, err := r.GetAccount(coaid, id)
+	if err != nil {
+		return nil, err
+	}
+	if old == nil {
+		return nil, fmt.Errorf("Account %v %w", id, ErrNotFound)
+	}
+	account := old.Clone()
+	for key, value := range patch {
+		switch {
+		case key == "name":
+			name, ok := value.(string)
+			if !ok {
+				return nil, fmt.Errorf("Invalid argument: name must be a string")
+			}
+			account.Name = name
+		case key == "tags":
+			tags, ok := patchTags(value)
+			if !ok {
+				return nil, fmt.Errorf("Invalid argument: tags must be a list of strings")
+			}
+			account.Tags = tags
+		case immutableFields[key]:
+			return nil, fmt.Errorf("Invalid argument: %v can't be patched", key)
+		default:
+			return nil, fmt.Errorf("Invalid argument: unknown field %v", key)
+		}
+	}
+	return account, nil
+}
+
+// patchTags accepts both []string and the []interface{} JSON decodes to.
+func patchTags(value interface{}) (Tags, bool) {
+	switch value := value.(type) {
+	case []string:
+		return append(Tags(nil), value...), true
+	case Tags:
+		return append(Tags(nil), value...), true
+	case []interface{}:
+		tags := make(Tags, len(value))
+		for i, v := range value {
+			s, ok := v.(string)
+			if !ok {
+				return nil, false
+			}
+			tags[i] = s
+		}
+		return tags, true
+	}
+	return nil, false
+}
@@ -0,0 +1,47 @@
+package coa
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestPatchAccount(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a, err = r.PatchAccount(coa.Id, a.Id, map[string]interface{}{"name": "Cash"})
+	check(t, err)
+	if a.Name != "Cash" || a.Number != "1" || !a.Tags.Equal(Tags{"balanceSheet", "increaseOnDebit", "detail"}) {
+		t.Errorf("Expected only the name changed but was %v", a)
+	}
+	var patch map[string]interface{}
+	check(t, json.Unmarshal([]byte(`{"tags": ["balanceSheet", "increaseOnCredit", "detail"]}`), &patch))
+	a, err = r.PatchAccount(coa.Id, a.Id, patch)
+	check(t, err)
+	if a.Name != "Cash" || !a.Tags.Equal(Tags{"balanceSheet", "increaseOnCredit", "detail"}) {
+		t.Errorf("Expected only the tags changed but was %v", a)
+	}
+	if _, err := r.PatchAccount(coa.Id, a.Id, map[string]interface{}{"number": "2"}); err == nil || err.Error() != "Invalid argument: number can't be patched" {
+		t.Errorf("Expected an immutable field error but was %v", err)
+	}
+	if _, err := r.PatchAccount(coa.Id, a.Id, map[string]interface{}{"color": "red"}); err == nil || err.Error() != "Invalid argument: unknown field color" {
+		t.Errorf("Expected an unknown field error but was %v", err)
+	}
+	if _, err := r.PatchAccount(coa.Id, a.Id, map[string]interface{}{"name": 1}); err == nil {
+		t.Error("Expected an error for a name that isn't a string")
+	}
+	if _, err := r.PatchAccount(coa.Id, a.Id, map[string]interface{}{"name": ""}); err == nil || err.Error() != "The name must be informed" {
+		t.Errorf("Expected a validation error but was %v", err)
+	}
+	if _, err := r.PatchAccount(coa.Id, "unknown", map[string]interface{}{"name": "x"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+	stored, err := r.GetAccount(coa.Id, a.Id)
+	check(t, err)
+	if stored.Name != "Cash" {
+		t.Errorf("Expected Cash but was %v", stored.Name)
+	}
+}
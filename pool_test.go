@@ -0,0 +1,112 @@
+package coa
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/tinylib/msgp/msgp"
+)
+
+func TestAllAccountsInto(t *testing.T) {
+	for _, perAccount := range []bool{false, true} {
+		r := NewCoaRepository(store{})
+		r.PerAccount = perAccount
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		var dst Accounts
+		check(t, r.AllAccountsInto(coa.Id, &dst))
+		if len(dst) != 0 {
+			t.Errorf("Expected no accounts but was %v", dst)
+		}
+		_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		check(t, r.AllAccountsInto(coa.Id, &dst))
+		if dst.String() != "1 a1 [balanceSheet detail increaseOnDebit]\n2 a2 [balanceSheet detail increaseOnDebit]" {
+			t.Errorf("Unexpected accounts %v", dst)
+		}
+		first := dst[0]
+		first.Name = "changed"
+		check(t, r.AllAccountsInto(coa.Id, &dst))
+		if dst[0].Name != "a1" {
+			t.Errorf("Expected a1 but was %v", dst[0].Name)
+		}
+		stored, err := r.GetAccount(coa.Id, dst[0].Id)
+		check(t, err)
+		if stored.Name != "a1" {
+			t.Errorf("Expected the store untouched but was %v", stored.Name)
+		}
+		ReleaseAccounts(dst)
+		if dst[0] != nil {
+			t.Error("Expected released accounts to be cleared")
+		}
+	}
+}
+
+func TestAllAccountsIntoResetsReusedAccounts(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccountAs(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}}, "john")
+	check(t, err)
+	var dst Accounts
+	check(t, r.AllAccountsInto(coa.Id, &dst))
+	if dst[0].ModifiedBy != "john" {
+		t.Errorf("Expected john but was %v", dst[0].ModifiedBy)
+	}
+	// A record written before ModifiedBy existed.
+	o := msgp.AppendArrayHeader(nil, 1)
+	o = msgp.AppendMapHeader(o, 3)
+	o = msgp.AppendString(o, "Id")
+	o = msgp.AppendString(o, "1")
+	o = msgp.AppendString(o, "Number")
+	o = msgp.AppendString(o, "1")
+	o = msgp.AppendString(o, "Name")
+	o = msgp.AppendString(o, "old")
+	check(t, r.putRaw("accounts/"+coa.Id, o))
+	check(t, r.AllAccountsInto(coa.Id, &dst))
+	if dst[0].Name != "old" || dst[0].ModifiedBy != "" || len(dst[0].Tags) != 0 {
+		t.Errorf("Expected old without user nor tags but was %+v", dst[0])
+	}
+}
+
+func benchmarkRepository(b *testing.B) (*CoaRepository, string) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	accounts := make(Accounts, 100)
+	for i := range accounts {
+		n := strconv.Itoa(i + 1)
+		accounts[i] = &Account{Id: n, Number: n, Name: "a" + n, Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}}
+	}
+	if err := r.put("accounts/"+coa.Id, accounts); err != nil {
+		b.Fatal(err)
+	}
+	return r, coa.Id
+}
+
+func BenchmarkAllAccounts(b *testing.B) {
+	r, coaid := benchmarkRepository(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.AllAccounts(coaid); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAllAccountsInto(b *testing.B) {
+	r, coaid := benchmarkRepository(b)
+	var dst Accounts
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.AllAccountsInto(coaid, &dst); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package coa
+
+// ValidationProfile tells how strictly SaveAccount validates accounts.
+type ValidationProfile int
+
+const (
+	// Strict rejects an account that breaks any rule.
+	Strict ValidationProfile = iota
+	// Lenient accepts an account whose tags differ from the inherited
+	// properties of its ancestors or that has more than one income
+	// statement attribute, and reports those problems in
+	// SaveAccountResult.Warnings instead. The other rules still reject the
+	// account.
+	Lenient
+)
@@ -0,0 +1,32 @@
+package coa
+
+import "testing"
+
+func TestLenientValidationProfile(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	child := &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"incomeStatement", "increaseOnDebit"}}
+	_, err = r.SaveAccount(coa.Id, child)
+	if err == nil || err.Error() != "The financial statement must be same as the parent" {
+		t.Errorf("Expected the strict profile to reject the child but was %v", err)
+	}
+	r.ValidationProfile = Lenient
+	child = &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"incomeStatement", "increaseOnDebit"}}
+	result, err := r.SaveAccountWithResult(coa.Id, child)
+	check(t, err)
+	if len(result.Warnings) != 1 || result.Warnings[0] != "The financial statement must be same as the parent" {
+		t.Errorf("Expected an inheritance warning but was %v", result.Warnings)
+	}
+	result, err = r.SaveAccountWithResult(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnDebit", "operating", "cost"}})
+	check(t, err)
+	if len(result.Warnings) != 1 || result.Warnings[0] != "Only one income statement attribute is allowed" {
+		t.Errorf("Expected an attribute warning but was %v", result.Warnings)
+	}
+	_, err = r.SaveAccountWithResult(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"incomeStatement"}})
+	if err == nil {
+		t.Error("Expected the lenient profile to still reject a missing normal balance")
+	}
+}
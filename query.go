@@ -0,0 +1,303 @@
+package coa
+
+import (
+	"fmt"
+	"strings"
+)
+
+type AccountQuery struct {
+	Tags []string
+	// AnyTags, when not empty, restricts the result to accounts with at
+	// least one of them.
+	AnyTags        []string
+	IncludeRemoved bool
+}
+
+func (r *CoaRepository) FindAccounts(coaid string, query AccountQuery) (Accounts, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	result := Accounts{}
+	for _, a := range aa {
+		if !a.Removed.IsZero() && !query.IncludeRemoved {
+			continue
+		}
+		if len(query.AnyTags) > 0 && !a.Tags.ContainsAny(query.AnyTags) {
+			continue
+		}
+		if a.Tags.ContainsAll(query.Tags) {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+func (r *CoaRepository) BalanceSheetAccounts(coaid string) (Accounts, error) {
+	return r.FindAccounts(coaid, AccountQuery{Tags: []string{"balanceSheet"}})
+}
+
+func (r *CoaRepository) IncomeStatementAccounts(coaid string) (Accounts, error) {
+	return r.FindAccounts(coaid, AccountQuery{Tags: []string{"incomeStatement"}})
+}
+
+func (r *CoaRepository) BalanceSheetDetailAccounts(coaid string) (Accounts, error) {
+	return r.FindAccounts(coaid, AccountQuery{Tags: []string{"balanceSheet", "detail"}})
+}
+
+func (r *CoaRepository) IncomeStatementDetailAccounts(coaid string) (Accounts, error) {
+	return r.FindAccounts(coaid, AccountQuery{Tags: []string{"incomeStatement", "detail"}})
+}
+
+func (r *CoaRepository) DetailAccounts(coaid string) (Accounts, error) {
+	return r.FindAccounts(coaid, AccountQuery{Tags: []string{"detail"}})
+}
+
+func (r *CoaRepository) SummaryAccounts(coaid string) (Accounts, error) {
+	return r.FindAccounts(coaid, AccountQuery{Tags: []string{"summary"}})
+}
+
+// RootAccounts returns the accounts without parent, sorted by number.
+// Removed accounts are left out.
+func (r *CoaRepository) RootAccounts(coaid string) (Accounts, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	result := Accounts{}
+	for _, a := range aa {
+		if a.Parent == "" && a.Removed.IsZero() {
+			result = append(result, a)
+		}
+	}
+	return result, nil
+}
+
+// ChildAccounts returns the immediate children of an account, sorted by
+// number, and an empty slice for a leaf. Removed accounts are left out.
+func (r *CoaRepository) ChildAccounts(coaid, parentId string) (Accounts, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	found := false
+	result := Accounts{}
+	for _, a := range aa {
+		if a.Id == parentId {
+			found = true
+		}
+		if a.Parent == parentId && a.Removed.IsZero() {
+			result = append(result, a)
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("Account %v %w", parentId, ErrNotFound)
+	}
+	return result, nil
+}
+
+func (r *CoaRepository) IsLeaf(coaid, id string) (bool, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, a := range aa {
+		if a.Id == id {
+			found = true
+		}
+		if a.Parent == id && a.Removed.IsZero() {
+			return false, nil
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("Account %v %w", id, ErrNotFound)
+	}
+	return true, nil
+}
+
+func (r *CoaRepository) GetAccounts(coaid string, ids []string) (map[string]*Account, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	result := make(map[string]*Account, len(ids))
+	for _, a := range aa {
+		if wanted[a.Id] {
+			result[a.Id] = a
+		}
+	}
+	return result, nil
+}
+
+// GetAccountsStrict is like GetAccounts but fails with ErrNotFound when any
+// of the ids is missing.
+func (r *CoaRepository) GetAccountsStrict(coaid string, ids []string) (map[string]*Account, error) {
+	result, err := r.GetAccounts(coaid, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		if _, ok := result[id]; !ok {
+			return nil, fmt.Errorf("Account %v %w", id, ErrNotFound)
+		}
+	}
+	return result, nil
+}
+
+// AccountsExist tells, for each id, whether the chart has that account.
+// Removed accounts count as missing.
+func (r *CoaRepository) AccountsExist(coaid string, ids []string) (map[string]bool, error) {
+	m, err := r.GetAccounts(coaid, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		a, ok := m[id]
+		result[id] = ok && a.Removed.IsZero()
+	}
+	return result, nil
+}
+
+// ResolveAccounts returns, for each id, the account carrying all the tags,
+// or nil when there is no such account.
+func (r *CoaRepository) ResolveAccounts(coaid string, ids []string, tags []string) ([]*Account, error) {
+	m, err := r.GetAccounts(coaid, ids)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Account, len(ids))
+	for i, id := range ids {
+		if a, ok := m[id]; ok && a.Tags.ContainsAll(tags) {
+			result[i] = a
+		}
+	}
+	return result, nil
+}
+
+// RetainedEarningsAccount returns the chart's retained earnings account. The
+// error wraps ErrNotFound when the chart has none, and ErrDanglingReference
+// when it refers to a missing account.
+func (r *CoaRepository) RetainedEarningsAccount(coaid string) (*Account, error) {
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	if coa == nil {
+		return nil, fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	if coa.RetainedEarningsAccount == "" {
+		return nil, fmt.Errorf("Retained earnings account %w", ErrNotFound)
+	}
+	a, err := r.GetAccount(coaid, coa.RetainedEarningsAccount)
+	if err != nil {
+		return nil, err
+	}
+	if a == nil {
+		return nil, fmt.Errorf("Retained earnings account %v %w", coa.RetainedEarningsAccount, ErrDanglingReference)
+	}
+	return a, nil
+}
+
+// IndexQuery asks IndexesMulti for the position of one account, which must
+// have all the Tags.
+type IndexQuery struct {
+	Id   string
+	Tags []string
+}
+
+// IndexesMulti is like Indexes but each account has its own tags. The
+// accounts are read once for all queries.
+func (r *CoaRepository) IndexesMulti(coaid string, queries []IndexQuery) ([]int, error) {
+	if coaid == "" {
+		return nil, fmt.Errorf("Invalid argument: coaid is empty")
+	}
+	accounts, err := r.loadAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	positions := make(map[string]int, len(accounts))
+	for j, a := range accounts {
+		positions[a.Id] = j
+	}
+	result := make([]int, len(queries))
+	for i, q := range queries {
+		result[i] = -1
+		if j, ok := positions[q.Id]; ok && accounts[j].Tags.ContainsAll(q.Tags) {
+			result[i] = j
+		}
+	}
+	return result, nil
+}
+
+// AccountPath returns the numbers, or names when byName is set, of the
+// account's ancestors and the account itself, from the root down, joined by
+// sep, such as "Assets > Current Assets > Cash".
+func (r *CoaRepository) AccountPath(coaid, id string, sep string, byName bool) (string, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return "", err
+	}
+	byId := make(map[string]*Account, len(aa))
+	for _, a := range aa {
+		byId[a.Id] = a
+	}
+	if byId[id] == nil {
+		return "", fmt.Errorf("Account %v %w", id, ErrNotFound)
+	}
+	var path []string
+	visited := map[string]bool{}
+	for a := byId[id]; a != nil && !visited[a.Id]; a = byId[a.Parent] {
+		visited[a.Id] = true
+		if byName {
+			path = append(path, a.Name)
+		} else {
+			path = append(path, a.Number)
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return strings.Join(path, sep), nil
+}
+
+// WalkTree visits the accounts depth first, parents before their children
+// and siblings in number order, with the depth of roots being zero. Removed
+// accounts and their descendants are left out, as are accounts that can't
+// be reached from a root. An error returned by visit stops the walk and is
+// returned.
+func (r *CoaRepository) WalkTree(coaid string, visit func(a *Account, depth int) error) error {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return err
+	}
+	children := map[string]Accounts{}
+	for _, a := range aa {
+		if a.Removed.IsZero() {
+			children[a.Parent] = append(children[a.Parent], a)
+		}
+	}
+	visited := map[string]bool{}
+	var walk func(parentId string, depth int) error
+	walk = func(parentId string, depth int) error {
+		for _, a := range children[parentId] {
+			if visited[a.Id] {
+				continue
+			}
+			visited[a.Id] = true
+			if err := visit(a, depth); err != nil {
+				return err
+			}
+			if err := walk(a.Id, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return walk("", 0)
+}
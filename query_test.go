@@ -0,0 +1,331 @@
+package coa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFindAccountsByStatement(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnCredit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"incomeStatement", "increaseOnCredit"}})
+	check(t, err)
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	accounts[3].Removed = time.Now()
+	check(t, r.put("accounts/"+coa.Id, accounts))
+
+	bs, err := r.BalanceSheetAccounts(coa.Id)
+	check(t, err)
+	if len(bs) != 2 || bs[0].Number != "1" || bs[1].Number != "1.1" {
+		t.Errorf("Expected 1 and 1.1 but was %v", bs)
+	}
+	bsd, err := r.BalanceSheetDetailAccounts(coa.Id)
+	check(t, err)
+	if len(bsd) != 1 || bsd[0].Number != "1.1" {
+		t.Errorf("Expected 1.1 but was %v", bsd)
+	}
+	is, err := r.IncomeStatementAccounts(coa.Id)
+	check(t, err)
+	if len(is) != 1 || is[0].Number != "2" {
+		t.Errorf("Expected 2 but was %v", is)
+	}
+}
+
+func TestDetailAndSummaryAccounts(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if !a11.IsDetail() || a11.IsSummary() {
+		t.Errorf("Expected a1.1 to be detail but was %v", a11.Tags)
+	}
+	summary, err := r.SummaryAccounts(coa.Id)
+	check(t, err)
+	if len(summary) != 1 || summary[0].Id != a1.Id || !summary[0].IsSummary() {
+		t.Errorf("Expected a1 to be the only summary account but was %v", summary)
+	}
+	detail, err := r.DetailAccounts(coa.Id)
+	check(t, err)
+	if len(detail) != 1 || detail[0].Id != a11.Id {
+		t.Errorf("Expected a1.1 to be the only detail account but was %v", detail)
+	}
+	leaf, err := r.IsLeaf(coa.Id, a1.Id)
+	check(t, err)
+	if leaf {
+		t.Error("Expected a1 not to be a leaf")
+	}
+	leaf, err = r.IsLeaf(coa.Id, a11.Id)
+	check(t, err)
+	if !leaf {
+		t.Error("Expected a1.1 to be a leaf")
+	}
+	if _, err = r.IsLeaf(coa.Id, "unknown"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for unknown account but was %v", err)
+	}
+}
+
+func TestGetAccounts(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	m, err := r.GetAccounts(coa.Id, []string{a1.Id, "unknown", a2.Id})
+	check(t, err)
+	if len(m) != 2 || m[a1.Id].Number != "1" || m[a2.Id].Number != "2" {
+		t.Errorf("Expected a1 and a2 but was %v", m)
+	}
+	_, err = r.GetAccountsStrict(coa.Id, []string{a1.Id, "unknown"})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+}
+
+func TestAccountsExist(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	check(t, r.DeleteAccount(coa.Id, a2.Id))
+	m, err := r.AccountsExist(coa.Id, []string{a1.Id, a2.Id, "unknown"})
+	check(t, err)
+	if len(m) != 3 || !m[a1.Id] || m[a2.Id] || m["unknown"] {
+		t.Errorf("Expected only a1 to exist but was %v", m)
+	}
+}
+
+func TestResolveAccounts(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnDebit"}})
+	check(t, err)
+	aa, err := r.ResolveAccounts(coa.Id, []string{a2.Id, "unknown", a1.Id}, []string{"increaseOnDebit"})
+	check(t, err)
+	if len(aa) != 3 || aa[0].Id != a2.Id || aa[1] != nil || aa[2].Id != a1.Id {
+		t.Errorf("Expected a2, nil, a1 but was %v", aa)
+	}
+	aa, err = r.ResolveAccounts(coa.Id, []string{a1.Id, a2.Id}, []string{"balanceSheet"})
+	check(t, err)
+	if aa[0] == nil || aa[1] != nil {
+		t.Errorf("Expected a1, nil but was %v", aa)
+	}
+}
+
+func TestRetainedEarningsAccount(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if _, err := r.RetainedEarningsAccount(coa.Id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	check(t, err)
+	retainedEarnings, err := r.RetainedEarningsAccount(coa.Id)
+	check(t, err)
+	if retainedEarnings.Id != a.Id {
+		t.Errorf("Expected %v but was %v", a.Id, retainedEarnings.Id)
+	}
+	check(t, r.put("accounts/"+coa.Id, Accounts{}))
+	_, err = r.RetainedEarningsAccount(coa.Id)
+	if !errors.Is(err, ErrDanglingReference) || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrDanglingReference but was %v", err)
+	}
+}
+
+func TestIndexesMulti(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnCredit"}})
+	check(t, err)
+	indexes, err := r.IndexesMulti(coa.Id, []IndexQuery{
+		{Id: a2.Id, Tags: []string{"incomeStatement"}},
+		{Id: a1.Id, Tags: []string{"incomeStatement"}},
+		{Id: a1.Id, Tags: []string{"balanceSheet", "detail"}},
+		{Id: "bogus"},
+	})
+	check(t, err)
+	expected := []int{1, -1, 0, -1}
+	for i, e := range expected {
+		if indexes[i] != e {
+			t.Errorf("Expected %v but was %v", expected, indexes)
+			break
+		}
+	}
+}
+
+func TestRootAndChildAccounts(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a12, err := r.SaveAccount(coa.Id, &Account{Number: "1.2", Name: "a1.2", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.2.1", Name: "a1.2.1", Parent: a12.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	roots, err := r.RootAccounts(coa.Id)
+	check(t, err)
+	if len(roots) != 2 || roots[0].Id != a1.Id || roots[1].Id != a2.Id {
+		t.Errorf("Expected 1 and 2 but was %v", roots)
+	}
+	children, err := r.ChildAccounts(coa.Id, a1.Id)
+	check(t, err)
+	if len(children) != 2 || children[0].Number != "1.1" || children[1].Number != "1.2" {
+		t.Errorf("Expected 1.1 and 1.2 but was %v", children)
+	}
+	children, err = r.ChildAccounts(coa.Id, a2.Id)
+	check(t, err)
+	if children == nil || len(children) != 0 {
+		t.Errorf("Expected an empty slice but was %#v", children)
+	}
+	if _, err := r.ChildAccounts(coa.Id, "bogus"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for an unknown parent but was %v", err)
+	}
+}
+
+func TestAccountPath(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "Assets", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "Current Assets", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a111, err := r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "Cash", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	path, err := r.AccountPath(coa.Id, a111.Id, " > ", true)
+	check(t, err)
+	if path != "Assets > Current Assets > Cash" {
+		t.Errorf("Unexpected path %q", path)
+	}
+	path, err = r.AccountPath(coa.Id, a111.Id, "/", false)
+	check(t, err)
+	if path != "1/1.1/1.1.1" {
+		t.Errorf("Unexpected path %q", path)
+	}
+	if _, err := r.AccountPath(coa.Id, "bogus", "/", false); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+	check(t, r.put("accounts/"+coa.Id, Accounts{
+		&Account{Id: "x", Number: "1", Name: "x", Parent: "y"},
+		&Account{Id: "y", Number: "2", Name: "y", Parent: "x"},
+	}))
+	path, err = r.AccountPath(coa.Id, "x", "/", true)
+	check(t, err)
+	if path != "y/x" {
+		t.Errorf("Unexpected path %q", path)
+	}
+}
+
+func TestFindAccountsAnyTags(t *testing.T) {
+	if (Tags{"a", "b"}).ContainsAny(nil) || !(Tags{"a", "b"}).ContainsAny([]string{"c", "b"}) || (Tags{"a"}).ContainsAny([]string{"c"}) {
+		t.Error("Unexpected ContainsAny result")
+	}
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"incomeStatement", "increaseOnCredit", "operating"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnDebit", "cost"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"incomeStatement", "increaseOnDebit", "incomeTax"}})
+	check(t, err)
+	aa, err := r.FindAccounts(coa.Id, AccountQuery{AnyTags: []string{"operating", "cost"}})
+	check(t, err)
+	if len(aa) != 2 || aa[0].Number != "1" || aa[1].Number != "2" {
+		t.Errorf("Expected 1 and 2 but was %v", aa)
+	}
+	aa, err = r.FindAccounts(coa.Id, AccountQuery{Tags: []string{"increaseOnDebit"}, AnyTags: []string{"operating", "cost"}})
+	check(t, err)
+	if len(aa) != 1 || aa[0].Number != "2" {
+		t.Errorf("Expected 2 but was %v", aa)
+	}
+	aa, err = r.FindAccounts(coa.Id, AccountQuery{})
+	check(t, err)
+	if len(aa) != 3 {
+		t.Errorf("Expected all accounts but was %v", aa)
+	}
+}
+
+func TestWalkTree(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.2", Name: "a12", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "a111", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	var rows []string
+	check(t, r.WalkTree(coa.Id, func(a *Account, depth int) error {
+		rows = append(rows, strings.Repeat(" ", depth)+a.Number)
+		return nil
+	}))
+	if strings.Join(rows, ",") != "1, 1.1,  1.1.1, 1.2,2" {
+		t.Errorf("Unexpected walk %q", rows)
+	}
+	stop := errors.New("stop")
+	rows = nil
+	err = r.WalkTree(coa.Id, func(a *Account, depth int) error {
+		rows = append(rows, a.Number)
+		if a.Number == "1.1" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || strings.Join(rows, ",") != "1,1.1" {
+		t.Errorf("Expected the walk to stop at 1.1 but was %v %v", err, rows)
+	}
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	for _, a := range accounts {
+		if a.Id == a1.Id {
+			a.Parent = a2.Id
+		}
+		if a.Id == a2.Id {
+			a.Parent = a11.Id
+		}
+	}
+	check(t, r.put("accounts/"+coa.Id, accounts))
+	rows = nil
+	check(t, r.WalkTree(coa.Id, func(a *Account, depth int) error {
+		rows = append(rows, a.Number)
+		return nil
+	}))
+	if len(rows) != 0 {
+		t.Errorf("Expected accounts in a cycle to be unreachable but was %v", rows)
+	}
+}
@@ -0,0 +1,105 @@
+package coa
+
+import (
+	"fmt"
+	"strings"
+)
+
+// chartRawKey is the key ExportRaw uses for the chart itself, which is
+// stored in a list shared with the other charts.
+func chartRawKey(coaid string) string { return "charts-of-accounts/" + coaid }
+
+// ExportRaw returns the stored bytes of a chart's accounts by key, in
+// whichever layout they are, for backup tools that don't decode them. The
+// chart itself is included under "charts-of-accounts/<coaid>". Keys don't
+// include KeyPrefix.
+func (r *CoaRepository) ExportRaw(coaid string) (map[string][]byte, error) {
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	if coa == nil {
+		return nil, fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	result := map[string][]byte{}
+	result[chartRawKey(coaid)], err = r.codec().Marshal(coa)
+	if err != nil {
+		return nil, err
+	}
+	keys := []string{"accounts/" + coaid, accountIndexKey(coaid)}
+	var index accountIndex
+	if err := r.get(accountIndexKey(coaid), &index); err != nil {
+		return nil, err
+	}
+	for _, e := range index {
+		keys = append(keys, accountKey(coaid, e.Id))
+	}
+	for _, key := range keys {
+		data, err := r.getRaw(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) > 0 {
+			result[key] = data
+		}
+	}
+	return result, nil
+}
+
+// ImportRaw writes back what ExportRaw returned. Every value is decoded
+// before anything is written, so bytes that don't decode leave the store
+// untouched. The chart replaces the chart with the same id, if any.
+func (r *CoaRepository) ImportRaw(data map[string][]byte) error {
+	var charts ChartsOfAccounts
+	for key, value := range data {
+		var v interface{}
+		switch parts := strings.Split(key, "/"); {
+		case len(parts) == 2 && parts[0] == "charts-of-accounts":
+			coa := &ChartOfAccounts{}
+			charts = append(charts, coa)
+			v = coa
+		case len(parts) == 2 && parts[0] == "accounts":
+			v = &Accounts{}
+		case len(parts) == 3 && parts[0] == "accounts":
+			v = &Account{}
+		case len(parts) == 2 && parts[0] == "account-index":
+			v = &accountIndex{}
+		default:
+			return fmt.Errorf("Invalid argument: unknown key %v", key)
+		}
+		if err := r.codec().Unmarshal(value, v); err != nil {
+			return fmt.Errorf("Invalid argument: %v: %w", key, err)
+		}
+	}
+	if len(charts) > 0 {
+		coas, err := r.AllChartsOfAccounts()
+		if err != nil {
+			return err
+		}
+		for _, coa := range charts {
+			coa.restoreZone()
+			replaced := false
+			for i, each := range coas {
+				if each.Id == coa.Id {
+					coas[i] = coa
+					replaced = true
+				}
+			}
+			if !replaced {
+				coas = append(coas, coa)
+			}
+		}
+		if err := r.put("charts-of-accounts", coas); err != nil {
+			return err
+		}
+	}
+	for key, value := range data {
+		if strings.HasPrefix(key, "charts-of-accounts/") {
+			continue
+		}
+		if err := r.putRaw(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package coa
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestExportAndImportRaw(t *testing.T) {
+	for _, perAccount := range []bool{false, true} {
+		asOf := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("", -3*60*60))
+		r := NewCoaRepositoryWithClock(store{}, fixedClock(asOf))
+		r.PerAccount = perAccount
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		data, err := r.ExportRaw(coa.Id)
+		check(t, err)
+		expectedKeys := 2
+		if perAccount {
+			expectedKeys = 4
+		}
+		if len(data) != expectedKeys {
+			t.Errorf("Expected %v keys but was %v", expectedKeys, len(data))
+		}
+
+		s := store{}
+		restored := NewCoaRepository(s)
+		restored.PerAccount = perAccount
+		if err := restored.ImportRaw(map[string][]byte{"accounts/" + coa.Id: []byte{0xc1}}); err == nil {
+			t.Error("Expected bytes that don't decode to be rejected")
+		}
+		if len(s) != 0 {
+			t.Errorf("Expected nothing written but was %v", s)
+		}
+		check(t, restored.ImportRaw(data))
+		for key, value := range data {
+			if key == chartRawKey(coa.Id) {
+				continue
+			}
+			if !bytes.Equal(s[key], value) {
+				t.Errorf("Expected %v to be restored byte by byte", key)
+			}
+		}
+		restoredCoa, err := restored.GetChartOfAccounts(coa.Id)
+		check(t, err)
+		if _, offset := restoredCoa.AsOf.Zone(); !restoredCoa.AsOf.Equal(asOf) || offset != -3*60*60 {
+			t.Errorf("Expected %v but was %v", asOf, restoredCoa.AsOf)
+		}
+		accounts, err := restored.AllAccounts(coa.Id)
+		check(t, err)
+		if len(accounts) != 2 {
+			t.Errorf("Expected 2 accounts but was %v", accounts)
+		}
+		chart, err := restored.GetChartOfAccounts(coa.Id)
+		check(t, err)
+		if chart == nil || chart.Name != "coa" {
+			t.Errorf("Expected the chart to be restored but was %v", chart)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package coa
+
+// readOnlyStore rejects every write to the wrapped store. It doesn't
+// implement KeyValueDeleter, so deletes also reach Put and fail.
+type readOnlyStore struct {
+	store KeyValueStore
+}
+
+func (s readOnlyStore) Get(key []byte) ([]byte, error) { return s.store.Get(key) }
+
+func (s readOnlyStore) Put(key []byte, value []byte) error { return ErrReadOnly }
+
+// Snapshot forwards to the wrapped store, keeping the view read-only. When
+// the wrapped store isn't a KeyValueSnapshotter the view is the store
+// itself, as if Snapshot weren't implemented.
+func (s readOnlyStore) Snapshot() (KeyValueStore, error) {
+	snapshotter, ok := s.store.(KeyValueSnapshotter)
+	if !ok {
+		return s, nil
+	}
+	view, err := snapshotter.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return readOnlyStore{view}, nil
+}
+
+// ReadOnly returns a copy of the repository that reads the same store but
+// fails every write with ErrReadOnly, so it can be handed to code that must
+// never change the charts. The copy shares the repository's change feed, and
+// its Close does nothing.
+func (r *CoaRepository) ReadOnly() *CoaRepository {
+	readOnly := *r
+	readOnly.store = readOnlyStore{r.store}
+	return &readOnly
+}
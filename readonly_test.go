@@ -0,0 +1,32 @@
+package coa
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadOnly(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	ro := r.ReadOnly()
+	accounts, err := ro.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 1 {
+		t.Errorf("Expected 1 account but was %v", accounts)
+	}
+	if _, err := ro.SaveChartOfAccounts(&ChartOfAccounts{Name: "other"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly but was %v", err)
+	}
+	if _, err := ro.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly but was %v", err)
+	}
+	if err := ro.DeleteAccount(coa.Id, a.Id); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly but was %v", err)
+	}
+	if _, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}}); err != nil {
+		t.Errorf("Expected the original repository to still write but was %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+package coa
+
+// An account is either active or removed. SaveAccount creates it active at
+// Created. DeleteAccount moves an active account to removed, setting
+// Removed. RestoreAccount moves a removed account back to active, clearing
+// Removed and setting Reactivated. Removed accounts can't be updated, and
+// an account removed and restored several times keeps only the times of
+// its latest removal and restoration; the Logger sees every transition.
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeleteAccount removes an account by setting its Removed time. The account
+// stays in the store, so that postings referring to it can still be read,
+// and can be brought back with RestoreAccount. Accounts with children that
+// are not removed and the retained earnings account can't be removed.
+func (r *CoaRepository) DeleteAccount(coaid, id string) error {
+	_, err := r.DeleteAccountWithResult(coaid, id)
+	return err
+}
+
+// DeleteAccountWithResult is like DeleteAccount but returns the removed
+// account. Removing an account changes no other.
+func (r *CoaRepository) DeleteAccountWithResult(coaid, id string) (*MutationResult, error) {
+	account, err := r.deleteAccount(coaid, id)
+	if err != nil {
+		return nil, r.observeFailure("DeleteAccount", err)
+	}
+	return &MutationResult{Primary: account}, nil
+}
+
+func (r *CoaRepository) deleteAccount(coaid, id string) (*Account, error) {
+	coa, accounts, account, err := r.accountForLifecycle(coaid, id)
+	if err != nil {
+		return nil, err
+	}
+	if !account.Removed.IsZero() {
+		return nil, fmt.Errorf("Account %v %w", id, ErrRemoved)
+	}
+	if coa.RetainedEarningsAccount == id {
+		return nil, ValidationError("The retained earnings account cannot be removed")
+	}
+	for _, a := range accounts {
+		if a.Parent == id && a.Removed.IsZero() {
+			return nil, ValidationError("The account has children and cannot be removed")
+		}
+	}
+	account.Removed = r.Clock.Now()
+	account.AsOf = account.Removed
+	if err := r.storeAccount(coaid, accounts, account, false); err != nil {
+		return nil, err
+	}
+	r.logAccountEvent("account.deleted", coaid, account, false)
+	r.publishAccounts(coaid, DeleteOperation, account)
+	return account, nil
+}
+
+// DeleteAccounts removes several accounts like DeleteAccount, reading and
+// writing the chart once. A whole subtree can be removed in one call, since
+// children are removed before their parents. Either every account is
+// removed or none is: when some of them are the retained earnings account or
+// have children left out of ids, the ValidationError lists them.
+func (r *CoaRepository) DeleteAccounts(coaid string, ids []string) error {
+	return r.observeFailure("DeleteAccounts", r.deleteAccounts(coaid, ids))
+}
+
+func (r *CoaRepository) deleteAccounts(coaid string, ids []string) error {
+	if coaid == "" {
+		return fmt.Errorf("Invalid argument: coaid is empty")
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return err
+	}
+	if coa == nil {
+		return fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	accounts, err := r.loadAccounts(coaid)
+	if err != nil {
+		return err
+	}
+	byId := make(map[string]*Account, len(accounts))
+	for _, a := range accounts {
+		byId[a.Id] = a
+	}
+	targets := map[string]bool{}
+	var targeted Accounts
+	for _, id := range ids {
+		a := byId[id]
+		if a == nil {
+			return fmt.Errorf("Account %v %w", id, ErrNotFound)
+		}
+		if !a.Removed.IsZero() {
+			return fmt.Errorf("Account %v %w", id, ErrRemoved)
+		}
+		if !targets[id] {
+			targets[id] = true
+			targeted = append(targeted, a)
+		}
+	}
+	var blocked []string
+	for _, a := range targeted {
+		if a.Id == coa.RetainedEarningsAccount {
+			blocked = append(blocked, a.Id+" (retained earnings account)")
+		}
+	}
+	for _, a := range targeted {
+		for _, child := range accounts {
+			if child.Parent == a.Id && child.Removed.IsZero() && !targets[child.Id] {
+				blocked = append(blocked, a.Id+" (has children)")
+				break
+			}
+		}
+	}
+	if len(blocked) > 0 {
+		return ValidationError("The accounts cannot be removed: " + strings.Join(blocked, ", "))
+	}
+	depth := make(map[string]int, len(targeted))
+	for _, a := range targeted {
+		visited := map[string]bool{}
+		for p := byId[a.Parent]; p != nil && !visited[p.Id]; p = byId[p.Parent] {
+			visited[p.Id] = true
+			depth[a.Id]++
+		}
+	}
+	sort.SliceStable(targeted, func(i, j int) bool { return depth[targeted[i].Id] > depth[targeted[j].Id] })
+	now := r.Clock.Now()
+	for _, a := range targeted {
+		a.Removed = now
+		a.AsOf = now
+	}
+	if r.PerAccount {
+		for _, a := range targeted {
+			if err := r.storeAccount(coaid, accounts, a, false); err != nil {
+				return err
+			}
+		}
+	} else if err := r.storeAccounts(coaid, accounts); err != nil {
+		return err
+	}
+	for _, a := range targeted {
+		r.logAccountEvent("account.deleted", coaid, a, false)
+		r.publishAccounts(coaid, DeleteOperation, a)
+	}
+	return nil
+}
+
+// RestoreAccount brings back an account removed by DeleteAccount. Its parent,
+// if any, must not be removed.
+func (r *CoaRepository) RestoreAccount(coaid, id string) (*Account, error) {
+	_, accounts, account, err := r.accountForLifecycle(coaid, id)
+	if err != nil {
+		return nil, r.observeFailure("RestoreAccount", err)
+	}
+	if account.Removed.IsZero() {
+		return nil, r.observeFailure("RestoreAccount", ValidationError("The account is not removed"))
+	}
+	for _, a := range accounts {
+		if a.Id == account.Parent && !a.Removed.IsZero() {
+			return nil, r.observeFailure("RestoreAccount", ValidationError("The parent is removed and must be restored first"))
+		}
+	}
+	account.Removed = time.Time{}
+	account.Reactivated = r.Clock.Now()
+	account.AsOf = account.Reactivated
+	if err := r.storeAccount(coaid, accounts, account, false); err != nil {
+		return nil, r.observeFailure("RestoreAccount", err)
+	}
+	r.logAccountEvent("account.restored", coaid, account, false)
+	r.publishAccounts(coaid, UpdateOperation, account)
+	return account, nil
+}
+
+func (r *CoaRepository) accountForLifecycle(coaid, id string) (*ChartOfAccounts, Accounts, *Account, error) {
+	if coaid == "" {
+		return nil, nil, nil, fmt.Errorf("Invalid argument: coaid is empty")
+	}
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if coa == nil {
+		return nil, nil, nil, fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	accounts, err := r.loadAccounts(coaid)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, a := range accounts {
+		if a.Id == id {
+			return coa, accounts, a, nil
+		}
+	}
+	return nil, nil, nil, fmt.Errorf("Account %v %w", id, ErrNotFound)
+}
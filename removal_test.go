@@ -0,0 +1,133 @@
+package coa
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestUpdateWithBogusId(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Id: "bogus", Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound but was %v", err)
+	}
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if len(accounts) != 0 {
+		t.Errorf("Expected no accounts but was %v", accounts)
+	}
+}
+
+func TestDeleteAndRestoreAccount(t *testing.T) {
+	for _, perAccount := range []bool{false, true} {
+		r := NewCoaRepository(store{})
+		r.PerAccount = perAccount
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		var validationError ValidationError
+		if err := r.DeleteAccount(coa.Id, a1.Id); !errors.As(err, &validationError) {
+			t.Errorf("Expected a validation error for a parent but was %v", err)
+		}
+		check(t, r.DeleteAccount(coa.Id, a11.Id))
+		removed, err := r.GetAccount(coa.Id, a11.Id)
+		check(t, err)
+		if removed.Removed.IsZero() {
+			t.Error("Expected the account to be removed")
+		}
+		if err := r.DeleteAccount(coa.Id, a11.Id); !errors.Is(err, ErrRemoved) {
+			t.Errorf("Expected ErrRemoved but was %v", err)
+		}
+		_, err = r.SaveAccount(coa.Id, &Account{Id: a11.Id, Number: "1.1", Name: "renamed", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		if !errors.Is(err, ErrRemoved) {
+			t.Errorf("Expected ErrRemoved but was %v", err)
+		}
+		check(t, r.DeleteAccount(coa.Id, a1.Id))
+		if _, err := r.RestoreAccount(coa.Id, a11.Id); !errors.As(err, &validationError) {
+			t.Errorf("Expected a validation error for a removed parent but was %v", err)
+		}
+		_, err = r.RestoreAccount(coa.Id, a1.Id)
+		check(t, err)
+		restored, err := r.RestoreAccount(coa.Id, a11.Id)
+		check(t, err)
+		if !restored.Removed.IsZero() || restored.Reactivated.IsZero() {
+			t.Error("Expected the account to be restored")
+		}
+		restored, err = r.GetAccount(coa.Id, a11.Id)
+		check(t, err)
+		if restored.Reactivated.IsZero() {
+			t.Errorf("Expected the reactivation to be stored but was %v", restored.Reactivated)
+		}
+		_, err = r.SaveAccount(coa.Id, &Account{Id: a11.Id, Number: "1.1", Name: "renamed", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		if err := r.DeleteAccount(coa.Id, "bogus"); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound but was %v", err)
+		}
+	}
+}
+
+func TestDeleteRetainedEarningsAccount(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	check(t, err)
+	if err := r.DeleteAccount(coa.Id, a.Id); err == nil {
+		t.Error("Expected the retained earnings account not to be removable")
+	}
+}
+
+func TestDeleteAccounts(t *testing.T) {
+	for _, perAccount := range []bool{false, true} {
+		r := NewCoaRepository(store{})
+		r.PerAccount = perAccount
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		a111, err := r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "a111", Parent: a11.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+		check(t, err)
+		err = r.DeleteAccounts(coa.Id, []string{a1.Id, a11.Id, a2.Id})
+		if err == nil || err.Error() != "The accounts cannot be removed: "+a2.Id+" (retained earnings account), "+a11.Id+" (has children)" {
+			t.Errorf("Expected a2 and a11 to block but was %v", err)
+		}
+		aa, err := r.AllAccounts(coa.Id)
+		check(t, err)
+		for _, a := range aa {
+			if !a.Removed.IsZero() {
+				t.Errorf("Expected nothing removed but %v was", a.Number)
+			}
+		}
+		ch := r.Subscribe()
+		check(t, r.DeleteAccounts(coa.Id, []string{a1.Id, a111.Id, a11.Id}))
+		r.Unsubscribe(ch)
+		var order []string
+		for e := range ch {
+			order = append(order, e.Id)
+		}
+		if strings.Join(order, ",") != a111.Id+","+a11.Id+","+a1.Id {
+			t.Errorf("Expected leaves first but was %v", order)
+		}
+		aa, err = r.AllAccounts(coa.Id)
+		check(t, err)
+		if len(aa) != 4 || aa[0].Removed.IsZero() || aa[1].Removed.IsZero() || aa[2].Removed.IsZero() || !aa[3].Removed.IsZero() {
+			t.Errorf("Expected the subtree of 1 removed but was %v", aa)
+		}
+		if err := r.DeleteAccounts(coa.Id, []string{a1.Id}); !errors.Is(err, ErrRemoved) {
+			t.Errorf("Expected ErrRemoved but was %v", err)
+		}
+		if err := r.DeleteAccounts(coa.Id, []string{"unknown"}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Expected ErrNotFound but was %v", err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package coa
+
+type RepairOptions struct {
+	// DropDanglingParents makes accounts whose parent doesn't exist roots.
+	DropDanglingParents bool
+	// FixDetailSummary tags accounts with children as summary and accounts
+	// without children as detail.
+	FixDetailSummary bool
+	// ClearDanglingRetainedEarnings clears a RetainedEarningsAccount that
+	// doesn't point to an existing account.
+	ClearDanglingRetainedEarnings bool
+}
+
+// RepairReport lists the ids of the accounts changed by each fix.
+type RepairReport struct {
+	DroppedParents          []string
+	FixedDetailSummary      []string
+	ClearedRetainedEarnings bool
+}
+
+func (r *CoaRepository) Repair(coaid string, opts RepairOptions) (RepairReport, error) {
+	var report RepairReport
+	if !opts.DropDanglingParents && !opts.FixDetailSummary && !opts.ClearDanglingRetainedEarnings {
+		return report, nil
+	}
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return report, r.observeFailure("Repair", err)
+	}
+	byId := map[string]*Account{}
+	for _, a := range aa {
+		byId[a.Id] = a
+	}
+	changed := map[string]bool{}
+	if opts.DropDanglingParents {
+		for _, a := range aa {
+			if a.Parent != "" && byId[a.Parent] == nil {
+				a.Parent = ""
+				changed[a.Id] = true
+				report.DroppedParents = append(report.DroppedParents, a.Id)
+			}
+		}
+	}
+	if opts.FixDetailSummary {
+		hasChildren := map[string]bool{}
+		for _, a := range aa {
+			if a.Parent != "" && a.Removed.IsZero() {
+				hasChildren[a.Parent] = true
+			}
+		}
+		for _, a := range aa {
+			tags := a.Tags.Without(Tags{"detail", "summary"})
+			if hasChildren[a.Id] {
+				tags = append(tags, "summary")
+			} else {
+				tags = append(tags, "detail")
+			}
+			if !tags.Equal(a.Tags) {
+				a.Tags = tags
+				changed[a.Id] = true
+				report.FixedDetailSummary = append(report.FixedDetailSummary, a.Id)
+			}
+		}
+	}
+	if len(changed) > 0 {
+		for _, a := range aa {
+			if changed[a.Id] {
+				a.AsOf = r.Clock.Now()
+			}
+		}
+		if err := r.storeAccounts(coaid, aa); err != nil {
+			return report, r.observeFailure("Repair", err)
+		}
+		for _, a := range aa {
+			if changed[a.Id] {
+				r.logAccount(coaid, a, false)
+				r.publishAccounts(coaid, UpdateOperation, a)
+			}
+		}
+	}
+	if opts.ClearDanglingRetainedEarnings {
+		coa, err := r.GetChartOfAccounts(coaid)
+		if err != nil {
+			return report, r.observeFailure("Repair", err)
+		}
+		if coa != nil && coa.RetainedEarningsAccount != "" && byId[coa.RetainedEarningsAccount] == nil {
+			coa.RetainedEarningsAccount = ""
+			if _, err := r.SaveChartOfAccounts(coa); err != nil {
+				return report, r.observeFailure("Repair", err)
+			}
+			report.ClearedRetainedEarnings = true
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,58 @@
+package coa
+
+import "testing"
+
+func TestRepair(t *testing.T) {
+	s := store{}
+	r := NewCoaRepository(s)
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	accounts[2].Tags = Tags{"balanceSheet", "increaseOnDebit", "summary"}
+	check(t, r.put("accounts/"+coa.Id, Accounts{accounts[1], accounts[2]}))
+
+	before := string(s["accounts/"+coa.Id])
+	report, err := r.Repair(coa.Id, RepairOptions{})
+	check(t, err)
+	if string(s["accounts/"+coa.Id]) != before || len(report.DroppedParents) != 0 {
+		t.Error("Expected nothing to be written when no fix is enabled")
+	}
+
+	report, err = r.Repair(coa.Id, RepairOptions{DropDanglingParents: true, FixDetailSummary: true, ClearDanglingRetainedEarnings: true})
+	check(t, err)
+	if len(report.DroppedParents) != 1 || report.DroppedParents[0] != a11.Id {
+		t.Errorf("Expected a1.1 parent to be dropped but was %v", report.DroppedParents)
+	}
+	if len(report.FixedDetailSummary) != 1 || report.FixedDetailSummary[0] != a2.Id {
+		t.Errorf("Expected a2 tags to be fixed but was %v", report.FixedDetailSummary)
+	}
+	if report.ClearedRetainedEarnings {
+		t.Error("Expected retained earnings account to be kept")
+	}
+	a, err := r.GetAccount(coa.Id, a11.Id)
+	check(t, err)
+	if a.Parent != "" {
+		t.Errorf("Expected a1.1 to be a root but parent was %v", a.Parent)
+	}
+	a, err = r.GetAccount(coa.Id, a2.Id)
+	check(t, err)
+	if !a.IsDetail() || a.IsSummary() {
+		t.Errorf("Expected a2 to be detail but was %v", a.Tags)
+	}
+
+	check(t, r.put("accounts/"+coa.Id, Accounts{a}))
+	report, err = r.Repair(coa.Id, RepairOptions{ClearDanglingRetainedEarnings: true})
+	check(t, err)
+	coa, err = r.GetChartOfAccounts(coa.Id)
+	check(t, err)
+	if !report.ClearedRetainedEarnings || coa.RetainedEarningsAccount != "" {
+		t.Errorf("Expected retained earnings account to be cleared but was %v", coa.RetainedEarningsAccount)
+	}
+}
@@ -0,0 +1,45 @@
+package coa
+
+type SaveAccountResult struct {
+	Account *Account
+	// Created tells whether the save inserted the account rather than
+	// updated it.
+	Created bool
+	// ChangedParent is the parent turned into a summary account by the save.
+	ChangedParent *Account
+	// ChangedChart is the chart whose retained earnings account was set.
+	ChangedChart *ChartOfAccounts
+	// Warnings holds the rules the account breaks that the Lenient
+	// validation profile let through.
+	Warnings []string
+	// IgnoredTags holds the submitted tags that aren't known properties and
+	// were dropped from the account.
+	IgnoredTags []string
+}
+
+// Mutation returns the account saved and the parent it changed, if any.
+func (result *SaveAccountResult) Mutation() *MutationResult {
+	mutation := &MutationResult{Primary: result.Account}
+	if result.ChangedParent != nil {
+		mutation.AlsoChanged = append(mutation.AlsoChanged, result.ChangedParent)
+	}
+	return mutation
+}
+
+// MutationResult is the account an operation was asked to change and the
+// other accounts it changed as a side effect, so that callers can update
+// their copies without reading the whole chart again.
+type MutationResult struct {
+	Primary     *Account
+	AlsoChanged []*Account
+}
+
+// preparedAccount is what prepareAccount learned about an account while
+// normalizing and validating it.
+type preparedAccount struct {
+	coa                     *ChartOfAccounts
+	old                     *Account
+	retainedEarningsAccount bool
+	warnings                []string
+	ignoredTags             []string
+}
@@ -0,0 +1,28 @@
+package coa
+
+import "testing"
+
+func TestMutationResult(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	result, err := r.SaveAccountWithResult(coa.Id, &Account{Number: "1.1", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	mutation := result.Mutation()
+	a11 := mutation.Primary
+	if a11.Number != "1.1" || len(mutation.AlsoChanged) != 1 || mutation.AlsoChanged[0].Id != a1.Id || !mutation.AlsoChanged[0].IsSummary() {
+		t.Errorf("Expected 1.1 changing its parent but was %v %v", mutation.Primary, mutation.AlsoChanged)
+	}
+	mutation, err = r.RenumberAccountWithResult(coa.Id, a1.Id, "2")
+	check(t, err)
+	if mutation.Primary.Number != "2" || len(mutation.AlsoChanged) != 1 || mutation.AlsoChanged[0].Id != a11.Id || mutation.AlsoChanged[0].Number != "2.1" {
+		t.Errorf("Expected 2 renumbering 2.1 but was %v %v", mutation.Primary, mutation.AlsoChanged)
+	}
+	mutation, err = r.DeleteAccountWithResult(coa.Id, a11.Id)
+	check(t, err)
+	if mutation.Primary.Id != a11.Id || mutation.Primary.Removed.IsZero() || len(mutation.AlsoChanged) != 0 {
+		t.Errorf("Expected only 2.1 removed but was %v %v", mutation.Primary, mutation.AlsoChanged)
+	}
+}
@@ -0,0 +1,43 @@
+package coa
+
+import (
+	"errors"
+	"time"
+)
+
+// RetryPolicy tells a CoaRepository how to retry store operations that
+// fail with transient errors.
+type RetryPolicy struct {
+	// MaxAttempts is the number of times an operation is tried, including
+	// the first one. Values below 2 disable retries.
+	MaxAttempts int
+	// Backoff is the wait before the second attempt; it doubles before each
+	// following one.
+	Backoff time.Duration
+	// Retryable classifies errors returned by the store. Nil retries
+	// nothing. Validation errors and ErrReadOnly are never retried.
+	Retryable func(err error) bool
+}
+
+func (r *CoaRepository) withRetry(op func() error) error {
+	err := op()
+	p := r.Retry
+	if p == nil || p.Retryable == nil {
+		return err
+	}
+	backoff := p.Backoff
+	for attempt := 1; attempt < p.MaxAttempts && err != nil && retryable(p, err); attempt++ {
+		time.Sleep(backoff)
+		backoff *= 2
+		err = op()
+	}
+	return err
+}
+
+func retryable(p *RetryPolicy, err error) bool {
+	var validationError ValidationError
+	if errors.As(err, &validationError) || errors.Is(err, ErrReadOnly) {
+		return false
+	}
+	return p.Retryable(err)
+}
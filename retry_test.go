@@ -0,0 +1,65 @@
+package coa
+
+import (
+	"errors"
+	"testing"
+)
+
+var errTransient = errors.New("transient")
+
+// flakyStore fails the first failures calls with errTransient.
+type flakyStore struct {
+	store
+	failures int
+	calls    int
+}
+
+func (s *flakyStore) Get(key []byte) ([]byte, error) {
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return nil, errTransient
+	}
+	return s.store.Get(key)
+}
+
+func (s *flakyStore) Put(key []byte, value []byte) error {
+	s.calls++
+	if s.failures > 0 {
+		s.failures--
+		return errTransient
+	}
+	return s.store.Put(key, value)
+}
+
+func TestRetry(t *testing.T) {
+	s := &flakyStore{store: store{}}
+	r := NewCoaRepository(s)
+	s.failures = 1
+	if _, err := r.AllChartsOfAccounts(); !errors.Is(err, errTransient) {
+		t.Errorf("Expected no retries by default but was %v", err)
+	}
+	r.Retry = &RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return errors.Is(err, errTransient) }}
+	s.failures, s.calls = 2, 0
+	_, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	if s.calls != 4 {
+		t.Errorf("Expected 4 calls but was %v", s.calls)
+	}
+	s.failures, s.calls = 3, 0
+	if _, err := r.AllChartsOfAccounts(); !errors.Is(err, errTransient) {
+		t.Errorf("Expected the error after 3 attempts but was %v", err)
+	}
+	if s.calls != 3 {
+		t.Errorf("Expected 3 calls but was %v", s.calls)
+	}
+	r.Retry.Retryable = func(err error) bool { return true }
+	ro := r.ReadOnly()
+	s.failures, s.calls = 0, 0
+	if _, err := ro.SaveChartOfAccounts(&ChartOfAccounts{Name: "other"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expected ErrReadOnly but was %v", err)
+	}
+	if s.calls != 1 {
+		t.Errorf("Expected only the read before the write but was %v calls", s.calls)
+	}
+}
@@ -0,0 +1,74 @@
+package coa
+
+import "fmt"
+
+// KeyValueSnapshotter may be implemented by a KeyValueStore able to read
+// from a point-in-time view of itself, such as a read transaction. Snapshot
+// reads through it so that the chart and its accounts are consistent.
+type KeyValueSnapshotter interface {
+	Snapshot() (KeyValueStore, error)
+}
+
+// ChartSnapshot is a chart and its accounts read together. Its methods only
+// look at that data and never reach the store.
+type ChartSnapshot struct {
+	Chart *ChartOfAccounts
+	// Accounts are sorted by number and include the removed ones.
+	Accounts Accounts
+}
+
+// Snapshot reads a chart and its accounts for reporting. When the store
+// implements KeyValueSnapshotter both are read from a single view of it,
+// also through a ReadOnly copy; otherwise a write between the two reads may
+// be partially seen.
+func (r *CoaRepository) Snapshot(coaid string) (*ChartSnapshot, error) {
+	view := r
+	if s, ok := r.store.(KeyValueSnapshotter); ok {
+		store, err := s.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+		snapshot := *r
+		snapshot.store = store
+		view = &snapshot
+	}
+	coa, err := view.GetChartOfAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	if coa == nil {
+		return nil, fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	aa, err := view.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	return &ChartSnapshot{Chart: coa, Accounts: aa}, nil
+}
+
+// Account returns the account with the id, or nil.
+func (s *ChartSnapshot) Account(id string) *Account {
+	for _, a := range s.Accounts {
+		if a.Id == id {
+			return a
+		}
+	}
+	return nil
+}
+
+// RootAccounts is like CoaRepository.RootAccounts.
+func (s *ChartSnapshot) RootAccounts() Accounts {
+	return s.ChildAccounts("")
+}
+
+// ChildAccounts returns the immediate children of an account, sorted by
+// number, leaving out the removed ones.
+func (s *ChartSnapshot) ChildAccounts(parentId string) Accounts {
+	result := Accounts{}
+	for _, a := range s.Accounts {
+		if a.Parent == parentId && a.Removed.IsZero() {
+			result = append(result, a)
+		}
+	}
+	return result
+}
@@ -0,0 +1,78 @@
+package coa
+
+import "testing"
+
+type snapshotStore struct {
+	store
+	snapshots int
+}
+
+func (s *snapshotStore) Snapshot() (KeyValueStore, error) {
+	s.snapshots++
+	view := store{}
+	for k, v := range s.store {
+		view[k] = v
+	}
+	return view, nil
+}
+
+func TestSnapshotOfReadOnlyView(t *testing.T) {
+	s := &snapshotStore{store: store{}}
+	r := NewCoaRepository(s)
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	snapshot, err := r.ReadOnly().Snapshot(coa.Id)
+	check(t, err)
+	if s.snapshots != 1 || len(snapshot.Accounts) != 1 {
+		t.Errorf("Expected 1 store snapshot with 1 account but was %v %v", s.snapshots, snapshot.Accounts)
+	}
+	view, err := readOnlyStore{s}.Snapshot()
+	check(t, err)
+	if err := view.Put([]byte("k"), []byte("v")); err != ErrReadOnly {
+		t.Errorf("Expected ErrReadOnly but was %v", err)
+	}
+	snapshot, err = NewCoaRepository(store{}).ReadOnly().Snapshot(coa.Id)
+	if err == nil {
+		t.Errorf("Expected the chart not to be found but was %v", snapshot)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	s := &snapshotStore{store: store{}}
+	r := NewCoaRepository(s)
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a11", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	snapshot, err := r.Snapshot(coa.Id)
+	check(t, err)
+	if s.snapshots != 1 {
+		t.Errorf("Expected 1 store snapshot but was %v", s.snapshots)
+	}
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if snapshot.Chart.Name != "coa" || len(snapshot.Accounts) != 3 {
+		t.Errorf("Expected coa with 3 accounts but was %v %v", snapshot.Chart, snapshot.Accounts)
+	}
+	if roots := snapshot.RootAccounts(); len(roots) != 2 || roots[0].Number != "1" || roots[1].Number != "2" {
+		t.Errorf("Expected roots 1 and 2 but was %v", roots)
+	}
+	if children := snapshot.ChildAccounts(a1.Id); len(children) != 1 || children[0].Id != a11.Id {
+		t.Errorf("Expected child 1.1 but was %v", children)
+	}
+	if a := snapshot.Account(a11.Id); a == nil || a.Number != "1.1" {
+		t.Errorf("Expected 1.1 but was %v", a)
+	}
+	if a := snapshot.Account("unknown"); a != nil {
+		t.Errorf("Expected nil but was %v", a)
+	}
+	if _, err := NewCoaRepository(store{}).Snapshot("unknown"); err == nil {
+		t.Error("Expected an error for an unknown chart")
+	}
+}
@@ -0,0 +1,113 @@
+package coa
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortKey selects the order of the accounts returned by AllAccountsSorted.
+type SortKey int
+
+const (
+	// SortByNumber orders numbers segment by segment, comparing numeric
+	// segments as numbers, so that "1.2" precedes "1.10".
+	SortByNumber SortKey = iota
+	// SortByNumberLexical orders numbers as plain strings, so that "1.10"
+	// precedes "1.2".
+	SortByNumberLexical
+	// SortByName orders accounts by name.
+	SortByName
+	// SortByCreated orders accounts by creation time, oldest first.
+	SortByCreated
+)
+
+// AllAccountsSorted is like AllAccounts but lets the caller choose the order.
+// Ties are broken by number and then by id.
+func (r *CoaRepository) AllAccountsSorted(coaid string, by SortKey) (Accounts, error) {
+	if by < SortByNumber || by > SortByCreated {
+		return nil, fmt.Errorf("Invalid argument: unknown sort key %v", by)
+	}
+	result, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	switch by {
+	case SortByNumberLexical:
+		sort.SliceStable(result, func(i, j int) bool {
+			if c := strings.Compare(result[i].Number, result[j].Number); c != 0 {
+				return c < 0
+			}
+			return result[i].Id < result[j].Id
+		})
+	case SortByName:
+		sort.SliceStable(result, func(i, j int) bool {
+			return strings.Compare(result[i].Name, result[j].Name) < 0
+		})
+	case SortByCreated:
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].Created.Before(result[j].Created)
+		})
+	}
+	return result, nil
+}
+
+// CompareNumbers compares two account numbers split on ".", returning -1, 0
+// or 1. Numeric segments are compared as numbers, so "1.9" precedes "1.10",
+// and other segments fall back to string comparison.
+func CompareNumbers(a, b string) int {
+	return compareNumbers(a, b, ".")
+}
+
+// CompareNumbers is like the package function but splits on r.Separator.
+func (r *CoaRepository) CompareNumbers(a, b string) int {
+	return compareNumbers(a, b, r.Separator)
+}
+
+// compareNumbers compares two account numbers segment by segment. Segments
+// made only of digits are compared as numbers, other segments as strings.
+// An empty sep makes the whole number a single segment.
+func compareNumbers(a, b, sep string) int {
+	as, bs := []string{a}, []string{b}
+	if sep != "" {
+		as, bs = strings.Split(a, sep), strings.Split(b, sep)
+	}
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := compareSegments(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	if len(as) != len(bs) {
+		if len(as) < len(bs) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareSegments(a, b string) int {
+	if !isDigits(a) || !isDigits(b) {
+		return strings.Compare(a, b)
+	}
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,70 @@
+package coa
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllAccountsSorted(t *testing.T) {
+	now := time.Date(2017, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := fixedClock(now)
+	r := NewCoaRepositoryWithClock(store{}, clock)
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "c", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	for i, n := range []struct{ number, name string }{{"1.10", "a"}, {"1.2", "b"}, {"1.9", "d"}} {
+		r.Clock = fixedClock(now.Add(time.Duration(i+1) * time.Hour))
+		_, err = r.SaveAccount(coa.Id, &Account{Number: n.number, Name: n.name, Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+	}
+	for _, c := range []struct {
+		by       SortKey
+		expected []string
+	}{
+		{SortByNumber, []string{"1", "1.2", "1.9", "1.10"}},
+		{SortByNumberLexical, []string{"1", "1.10", "1.2", "1.9"}},
+		{SortByName, []string{"1.10", "1.2", "1", "1.9"}},
+		{SortByCreated, []string{"1", "1.10", "1.2", "1.9"}},
+	} {
+		accounts, err := r.AllAccountsSorted(coa.Id, c.by)
+		check(t, err)
+		for i, a := range accounts {
+			if a.Number != c.expected[i] {
+				t.Errorf("Expected %v but was %v at %v for %v", c.expected[i], a.Number, i, c.by)
+			}
+		}
+	}
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	if accounts[1].Number != "1.2" || accounts[3].Number != "1.10" {
+		t.Errorf("Expected natural order but was %v %v", accounts[1].Number, accounts[3].Number)
+	}
+	if _, err := r.AllAccountsSorted(coa.Id, SortKey(-1)); err == nil {
+		t.Error("Expected an error for an unknown sort key")
+	}
+}
+
+func TestCompareNumbers(t *testing.T) {
+	for _, c := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.10", "1.9", 1},
+		{"1.9", "1.10", -1},
+		{"10", "9", 1},
+		{"1.A", "1.2", 1},
+		{"1.2", "1.2", 0},
+		{"1", "1.1", -1},
+		{"1.02", "1.2", -1},
+	} {
+		if got := CompareNumbers(c.a, c.b); got != c.expected {
+			t.Errorf("Expected %v but was %v comparing %v and %v", c.expected, got, c.a, c.b)
+		}
+	}
+	r := NewCoaRepository(store{})
+	r.Separator = "-"
+	if got := r.CompareNumbers("1-10", "1-9"); got != 1 {
+		t.Errorf("Expected 1 but was %v", got)
+	}
+}
@@ -0,0 +1,116 @@
+package coa
+
+import (
+	"fmt"
+	"time"
+)
+
+// CopySubtree copies the account rootId and its descendants under
+// newParentId, or to the top level when newParentId is empty. The copies get
+// new ids, and numberPrefix replaces the root's number at the start of every
+// copied number. Removed accounts aren't copied. All copies are written at
+// once and returned root first.
+func (r *CoaRepository) CopySubtree(coaid, rootId, newParentId, numberPrefix string) ([]*Account, error) {
+	copies, err := r.copySubtree(coaid, rootId, newParentId, numberPrefix)
+	return copies, r.observeFailure("CopySubtree", err)
+}
+
+func (r *CoaRepository) copySubtree(coaid, rootId, newParentId, numberPrefix string) ([]*Account, error) {
+	if coaid == "" {
+		return nil, fmt.Errorf("Invalid argument: coaid is empty")
+	}
+	if numberPrefix == "" {
+		return nil, fmt.Errorf("Invalid argument: numberPrefix is empty")
+	}
+	accounts, err := r.loadAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]*Account, len(accounts))
+	for _, a := range accounts {
+		byId[a.Id] = a
+	}
+	root := byId[rootId]
+	if root == nil || !root.Removed.IsZero() {
+		return nil, fmt.Errorf("Account %v %w", rootId, ErrNotFound)
+	}
+	var parent *Account
+	if newParentId != "" {
+		parent = byId[newParentId]
+		if parent == nil || !parent.Removed.IsZero() {
+			return nil, fmt.Errorf("Parent not found: %v", newParentId)
+		}
+	}
+	var originals Accounts
+	for _, a := range append(Accounts{root}, descendantsOf(accounts, rootId)...) {
+		if a.Removed.IsZero() {
+			originals = append(originals, a)
+		}
+	}
+	if err := r.checkAccountLimit(coaid, len(originals)); err != nil {
+		return nil, err
+	}
+	now := r.Clock.Now()
+	ids := make(map[string]string, len(originals))
+	copies := make([]*Account, len(originals))
+	for i, a := range originals {
+		if ids[a.Id], err = r.newID(); err != nil {
+			return nil, err
+		}
+		c := a.Clone()
+		c.Id = ids[a.Id]
+		c.Parent = ids[a.Parent]
+		if hasNumberPrefix(a.Number, root.Number, r.Separator) {
+			c.Number = numberPrefix + a.Number[len(root.Number):]
+		} else {
+			c.Number = numberPrefix + r.Separator + a.Number
+		}
+		c.Number = r.normalizeSpace(c.Number)
+		c.Name = r.normalizeSpace(c.Name)
+		if msg := r.accountLimitsMessage(c); msg != "" {
+			return nil, ValidationError(msg)
+		}
+		if msg := separatorMessage(c.Number, r.Separator, r.NumberSeparators); msg != "" {
+			return nil, ValidationError(msg)
+		}
+		c.Created, c.AsOf, c.Reactivated = now, now, time.Time{}
+		copies[i] = c
+	}
+	copies[0].Parent = newParentId
+	converted := r.AutoManageDetailSummary && parent != nil && (parent.IsDetail() || !parent.IsSummary())
+	if converted {
+		if r.CanConvertToSummary != nil {
+			ok, err := r.CanConvertToSummary(coaid, parent.Id)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				return nil, ValidationError("Parent has postings and cannot become a summary account")
+			}
+		}
+		parent.Tags = append(parent.Tags.Without(Tags{"detail"}), "summary")
+		parent.AsOf = now
+	}
+	accounts = append(accounts, copies...)
+	copied := make(map[string]bool, len(copies))
+	for _, c := range copies {
+		copied[c.Id] = true
+	}
+	for _, issue := range validateAccounts(accounts, "", r.Separator) {
+		if copied[issue.AccountId] || parent != nil && issue.AccountId == parent.Id {
+			return nil, ValidationError(issue.Message)
+		}
+	}
+	if err := r.storeAccounts(coaid, accounts); err != nil {
+		return nil, err
+	}
+	for _, c := range copies {
+		r.logAccount(coaid, c, true)
+	}
+	r.publishAccounts(coaid, CreateOperation, copies...)
+	if converted {
+		r.logAccount(coaid, parent, false)
+		r.publishAccounts(coaid, UpdateOperation, parent)
+	}
+	return copies, nil
+}
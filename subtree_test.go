@@ -0,0 +1,70 @@
+package coa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCopySubtree(t *testing.T) {
+	for _, perAccount := range []bool{false, true} {
+		r := NewCoaRepository(store{})
+		r.PerAccount = perAccount
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "Sales", Tags: []string{"incomeStatement", "increaseOnCredit"}})
+		check(t, err)
+		a11, err := r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "Products", Parent: a1.Id, Tags: []string{"incomeStatement", "increaseOnCredit"}})
+		check(t, err)
+		_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1.1", Name: "Hardware", Parent: a11.Id, Tags: []string{"incomeStatement", "increaseOnCredit"}})
+		check(t, err)
+		a12, err := r.SaveAccount(coa.Id, &Account{Number: "1.2", Name: "Services", Parent: a1.Id, Tags: []string{"incomeStatement", "increaseOnCredit"}})
+		check(t, err)
+
+		if _, err := r.CopySubtree(coa.Id, a11.Id, a12.Id, "1.1"); err == nil || err.Error() != "An account with this number already exists" {
+			t.Errorf("Expected a number collision but was %v", err)
+		}
+		if _, err := r.CopySubtree(coa.Id, a11.Id, "bogus", "1.2.1"); err == nil {
+			t.Error("Expected an error for an unknown parent")
+		}
+		copies, err := r.CopySubtree(coa.Id, a11.Id, a12.Id, "1.2.1")
+		check(t, err)
+		if len(copies) != 2 || copies[0].Number != "1.2.1" || copies[1].Number != "1.2.1.1" {
+			t.Fatalf("Expected 1.2.1 and 1.2.1.1 but was %v", copies)
+		}
+		if copies[0].Id == a11.Id || copies[0].Parent != a12.Id || copies[1].Parent != copies[0].Id {
+			t.Errorf("Expected new ids and parents but was %v %v", copies[0], copies[1])
+		}
+		accounts, err := r.AllAccounts(coa.Id)
+		check(t, err)
+		if len(accounts) != 6 {
+			t.Errorf("Expected 6 accounts but was %v", len(accounts))
+		}
+		parent, err := r.GetAccount(coa.Id, a12.Id)
+		check(t, err)
+		if !parent.IsSummary() || parent.IsDetail() {
+			t.Errorf("Expected the new parent to be summary but was %v", parent.Tags)
+		}
+		if issues, err := r.Validate(coa.Id); err != nil || len(issues) != 0 {
+			t.Errorf("Expected a valid chart but was %v %v", issues, err)
+		}
+	}
+}
+
+func TestCopySubtreeChecksNumbers(t *testing.T) {
+	r := NewCoaRepository(store{})
+	r.NumberSeparators = ".-"
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	for _, prefix := range []string{"9\x00", "9-1", strings.Repeat("9", 100)} {
+		if _, err := r.CopySubtree(coa.Id, a1.Id, "", prefix); err == nil {
+			t.Errorf("Expected %q to be rejected", prefix)
+		}
+	}
+	copies, err := r.CopySubtree(coa.Id, a1.Id, "", " 9 ")
+	check(t, err)
+	if copies[0].Number != "9" {
+		t.Errorf("Expected 9 but was %q", copies[0].Number)
+	}
+}
@@ -0,0 +1,59 @@
+package coa
+
+import "fmt"
+
+// ChartSummary holds the account counts of a chart. Removed accounts are
+// only counted in Removed.
+type ChartSummary struct {
+	Accounts        int
+	BalanceSheet    int
+	IncomeStatement int
+	Detail          int
+	Summary         int
+	Removed         int
+	// RetainedEarningsSet tells whether the chart names a retained earnings
+	// account, and RetainedEarningsValid whether that account exists and
+	// isn't removed.
+	RetainedEarningsSet   bool
+	RetainedEarningsValid bool
+}
+
+// Summary counts the accounts of a chart, reading them once.
+func (r *CoaRepository) Summary(coaid string) (ChartSummary, error) {
+	var summary ChartSummary
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return summary, err
+	}
+	if coa == nil {
+		return summary, fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return summary, err
+	}
+	summary.RetainedEarningsSet = coa.RetainedEarningsAccount != ""
+	for _, a := range aa {
+		if !a.Removed.IsZero() {
+			summary.Removed++
+			continue
+		}
+		summary.Accounts++
+		if a.Tags.Contains("balanceSheet") {
+			summary.BalanceSheet++
+		}
+		if a.Tags.Contains("incomeStatement") {
+			summary.IncomeStatement++
+		}
+		if a.IsDetail() {
+			summary.Detail++
+		}
+		if a.IsSummary() {
+			summary.Summary++
+		}
+		if a.Id == coa.RetainedEarningsAccount {
+			summary.RetainedEarningsValid = true
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,33 @@
+package coa
+
+import "testing"
+
+func TestSummary(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1.1", Name: "a1.1", Parent: a1.Id, Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnDebit"}})
+	check(t, err)
+	a3, err := r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"incomeStatement", "increaseOnDebit"}})
+	check(t, err)
+	check(t, r.DeleteAccount(coa.Id, a3.Id))
+	summary, err := r.Summary(coa.Id)
+	check(t, err)
+	expected := ChartSummary{
+		Accounts:              3,
+		BalanceSheet:          2,
+		IncomeStatement:       1,
+		Detail:                2,
+		Summary:               1,
+		Removed:               1,
+		RetainedEarningsSet:   true,
+		RetainedEarningsValid: true,
+	}
+	if summary != expected {
+		t.Errorf("Expected %+v but was %+v", expected, summary)
+	}
+}
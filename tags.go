@@ -0,0 +1,98 @@
+package coa
+
+import (
+	"fmt"
+	"sort"
+)
+
+// structuralTags are the tags the validation rules depend on; RenameTag
+// refuses to touch them.
+var structuralTags = map[string]bool{
+	"balanceSheet":     true,
+	"incomeStatement":  true,
+	"increaseOnDebit":  true,
+	"increaseOnCredit": true,
+	"detail":           true,
+	"summary":          true,
+}
+
+// RenameTag replaces oldTag with newTag on every account of the chart in a
+// single write and returns how many accounts changed. Structural tags can't
+// be renamed nor be the new name, and newTag must be a known property, since
+// saving drops unknown tags.
+func (r *CoaRepository) RenameTag(coaid, oldTag, newTag string) (int, error) {
+	n, err := r.renameTag(coaid, oldTag, newTag)
+	return n, r.observeFailure("RenameTag", err)
+}
+
+func (r *CoaRepository) renameTag(coaid, oldTag, newTag string) (int, error) {
+	if oldTag == "" || newTag == "" {
+		return 0, fmt.Errorf("Invalid argument: tag is empty")
+	}
+	if structuralTags[oldTag] || structuralTags[newTag] {
+		return 0, fmt.Errorf("Invalid argument: structural tags can't be renamed")
+	}
+	if !isProperty(newTag) {
+		return 0, fmt.Errorf("Invalid argument: unknown tag %v", newTag)
+	}
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return 0, err
+	}
+	var changed Accounts
+	for _, a := range aa {
+		i := a.Tags.IndexOf(oldTag)
+		if i == -1 {
+			continue
+		}
+		a.Tags[i] = newTag
+		a.Tags = a.Tags.normalize()
+		a.AsOf = r.Clock.Now()
+		changed = append(changed, a)
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+	if err := r.storeAccounts(coaid, aa); err != nil {
+		return 0, err
+	}
+	for _, a := range changed {
+		r.logAccount(coaid, a, false)
+	}
+	r.publishAccounts(coaid, UpdateOperation, changed...)
+	return len(changed), nil
+}
+
+// UsedTags returns the distinct tags of the chart's accounts, sorted.
+// Removed accounts are ignored.
+func (r *CoaRepository) UsedTags(coaid string) ([]string, error) {
+	counts, err := r.TagCounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(counts))
+	for tag := range counts {
+		result = append(result, tag)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// TagCounts returns how many of the chart's accounts carry each tag.
+// Removed accounts are ignored.
+func (r *CoaRepository) TagCounts(coaid string) (map[string]int, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]int{}
+	for _, a := range aa {
+		if !a.Removed.IsZero() {
+			continue
+		}
+		for _, tag := range append(Tags(nil), a.Tags...).normalize() {
+			result[tag]++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package coa
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenameTag(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"incomeStatement", "increaseOnDebit", "cost"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnDebit", "operating"}})
+	check(t, err)
+	if _, err := r.RenameTag(coa.Id, "detail", "leaf"); err == nil {
+		t.Error("Expected renaming a structural tag to fail")
+	}
+	if _, err := r.RenameTag(coa.Id, "cost", "summary"); err == nil {
+		t.Error("Expected renaming to a structural tag to fail")
+	}
+	if _, err := r.RenameTag(coa.Id, "cost", "costOfSales"); err == nil {
+		t.Error("Expected renaming to an unknown tag to fail")
+	}
+	n, err := r.RenameTag(coa.Id, "cost", "deduction")
+	check(t, err)
+	if n != 1 {
+		t.Errorf("Expected 1 but was %v", n)
+	}
+	accounts, err := r.FindAccounts(coa.Id, AccountQuery{Tags: []string{"deduction"}})
+	check(t, err)
+	if len(accounts) != 1 || accounts[0].Number != "1" || accounts[0].Tags.Contains("cost") {
+		t.Errorf("Expected account 1 with deduction but was %v", accounts)
+	}
+}
+
+func TestUsedTags(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Tags: []string{"incomeStatement", "increaseOnCredit", "operating"}})
+	check(t, err)
+	a3, err := r.SaveAccount(coa.Id, &Account{Number: "3", Name: "a3", Tags: []string{"incomeStatement", "increaseOnDebit", "cost"}})
+	check(t, err)
+	check(t, r.DeleteAccount(coa.Id, a3.Id))
+	tags, err := r.UsedTags(coa.Id)
+	check(t, err)
+	if strings.Join(tags, " ") != "balanceSheet detail incomeStatement increaseOnCredit increaseOnDebit operating" {
+		t.Errorf("Unexpected tags %v", tags)
+	}
+	counts, err := r.TagCounts(coa.Id)
+	check(t, err)
+	if len(counts) != 6 || counts["detail"] != 2 || counts["operating"] != 1 || counts["cost"] != 0 {
+		t.Errorf("Unexpected counts %v", counts)
+	}
+}
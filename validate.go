@@ -0,0 +1,224 @@
+package coa
+
+import (
+	"fmt"
+	"sort"
+)
+
+type ValidationIssue struct {
+	AccountId string
+	Message   string
+}
+
+func (r *CoaRepository) Validate(coaid string) ([]ValidationIssue, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	retainedEarnings := ""
+	if coa != nil {
+		retainedEarnings = coa.RetainedEarningsAccount
+	}
+	issues := validateAccounts(aa, retainedEarnings, r.Separator)
+	if r.NumberSeparators != "" {
+		for _, a := range aa {
+			if !a.Removed.IsZero() {
+				continue
+			}
+			if msg := separatorMessage(a.Number, r.Separator, r.NumberSeparators); msg != "" {
+				issues = append(issues, ValidationIssue{AccountId: a.Id, Message: msg})
+			}
+		}
+	}
+	return issues, nil
+}
+
+// ValidateChart checks the chart itself: its name and, when it has one, its
+// retained earnings account, which must exist, not be removed, and be a
+// balance sheet account increasing on credit. A missing account is reported
+// with an error wrapping ErrDanglingReference, a removed one wrapping
+// ErrRemoved, and the rest as a ValidationError.
+func (r *CoaRepository) ValidateChart(coaid string) error {
+	return r.observeFailure("ValidateChart", r.validateChart(coaid))
+}
+
+func (r *CoaRepository) validateChart(coaid string) error {
+	coa, err := r.GetChartOfAccounts(coaid)
+	if err != nil {
+		return err
+	}
+	if coa == nil {
+		return fmt.Errorf("Chart of accounts not found: %v", coaid)
+	}
+	if msg := coa.ValidationMessage(); msg != "" {
+		return ValidationError(msg)
+	}
+	if coa.RetainedEarningsAccount == "" {
+		return nil
+	}
+	a, err := r.RetainedEarningsAccount(coaid)
+	if err != nil {
+		return err
+	}
+	if !a.Removed.IsZero() {
+		return fmt.Errorf("Retained earnings account %v %w", a.Id, ErrRemoved)
+	}
+	if !a.Tags.Contains("balanceSheet") {
+		return ValidationError("The retained earnings account must be a balance sheet account")
+	}
+	if !a.Tags.Contains("increaseOnCredit") {
+		return ValidationError("The retained earnings account must increase on credit")
+	}
+	return nil
+}
+
+// ValidateAccounts checks a whole chart in memory, without a repository.
+// It reports the same problems SaveAccount would reject, using "." as the
+// number separator, plus a retained earnings account missing from the
+// slice and, when there is a retained earnings account, balance sheet
+// accounts with an income statement attribute, which would confuse the
+// closing of periods. Removed accounts are ignored. Issues about the chart
+// itself have an empty AccountId.
+//
+// The only postable-only tag is detail, so a summary account is flagged
+// only when it is also tagged detail. Income statement attributes are
+// allowed on summary accounts, since their children inherit them.
+func ValidateAccounts(accounts Accounts, retainedEarnings string) []ValidationIssue {
+	return validateAccounts(accounts, retainedEarnings, ".")
+}
+
+func validateAccounts(accounts Accounts, retainedEarnings, sep string) []ValidationIssue {
+	byId := map[string]*Account{}
+	byNumber := map[string]*Account{}
+	var issues []ValidationIssue
+	for _, a := range accounts {
+		if !a.Removed.IsZero() {
+			continue
+		}
+		byId[a.Id] = a
+	}
+	for _, a := range accounts {
+		if !a.Removed.IsZero() {
+			continue
+		}
+		if msg := a.localValidationMessage(); msg != "" {
+			issues = append(issues, ValidationIssue{AccountId: a.Id, Message: msg})
+			continue
+		}
+		if retainedEarnings != "" && a.Tags.Contains("balanceSheet") {
+			if attribute := incomeStatementAttribute(a); attribute != "" {
+				issues = append(issues, ValidationIssue{AccountId: a.Id, Message: "A balance sheet account cannot have the income statement attribute " + attribute})
+				continue
+			}
+		}
+		if byNumber[a.Number] != nil {
+			issues = append(issues, ValidationIssue{AccountId: a.Id, Message: "An account with this number already exists"})
+			continue
+		}
+		byNumber[a.Number] = a
+		if a.Parent == "" {
+			continue
+		}
+		parent := byId[a.Parent]
+		if parent == nil {
+			issues = append(issues, ValidationIssue{AccountId: a.Id, Message: "Parent not found: " + a.Parent})
+			continue
+		}
+		if !hasNumberPrefix(a.Number, parent.Number, sep) {
+			issues = append(issues, ValidationIssue{AccountId: a.Id, Message: "The number must start with parent's number"})
+			continue
+		}
+		if msg := inheritanceMessage(a, byId); msg != "" {
+			issues = append(issues, ValidationIssue{AccountId: a.Id, Message: msg})
+		}
+	}
+	if retainedEarnings != "" && byId[retainedEarnings] == nil {
+		issues = append(issues, ValidationIssue{Message: "Retained earnings account not found: " + retainedEarnings})
+	}
+	return issues
+}
+
+// inheritanceMessage checks the account against each of its ancestors in
+// byId, as SaveAccount does, and returns the message for the nearest one
+// whose inherited properties it lacks, or "".
+func inheritanceMessage(a *Account, byId map[string]*Account) string {
+	visited := map[string]bool{a.Id: true}
+	for ancestor := byId[a.Parent]; ancestor != nil && !visited[ancestor.Id]; ancestor = byId[ancestor.Parent] {
+		visited[ancestor.Id] = true
+		if missing := CheckInheritance(a, Accounts{ancestor}); len(missing) > 0 {
+			description, _ := inheritedProperty(missing[0])
+			if ancestor.Id == a.Parent {
+				return "The " + description + " must be same as the parent"
+			}
+			return "The " + description + " must be same as the ancestor " + ancestor.Number
+		}
+	}
+	return ""
+}
+
+// incomeStatementAttribute returns the first, in sorted order, of the
+// income statement attributes of the account, or "" when it has none.
+func incomeStatementAttribute(a *Account) string {
+	var attributes []string
+	for _, tag := range a.Tags {
+		if description, _ := inheritedProperty(tag); description == "income statement attribute" {
+			attributes = append(attributes, tag)
+		}
+	}
+	if len(attributes) == 0 {
+		return ""
+	}
+	sort.Strings(attributes)
+	return attributes[0]
+}
+
+// CheckInheritance returns, sorted and without repetition, the inherited
+// properties some of the ancestors have and the account lacks.
+func CheckInheritance(account *Account, ancestors Accounts) []string {
+	seen := map[string]bool{}
+	var result []string
+	for _, ancestor := range ancestors {
+		for key := range inheritedProperties {
+			if !seen[key] && ancestor.Tags.Contains(key) && !account.Tags.Contains(key) {
+				seen[key] = true
+				result = append(result, key)
+			}
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+// AccountsMissingStatement returns the accounts, sorted by number, tagged
+// neither balanceSheet nor incomeStatement. Such accounts can only come from
+// data stored without going through SaveAccount.
+func (r *CoaRepository) AccountsMissingStatement(coaid string) (Accounts, error) {
+	return r.accountsMissing(coaid, "balanceSheet", "incomeStatement")
+}
+
+// AccountsMissingNormalBalance returns the accounts, sorted by number,
+// tagged neither increaseOnDebit nor increaseOnCredit.
+func (r *CoaRepository) AccountsMissingNormalBalance(coaid string) (Accounts, error) {
+	return r.accountsMissing(coaid, "increaseOnDebit", "increaseOnCredit")
+}
+
+// accountsMissing returns the accounts that aren't removed and have none of
+// the tags.
+func (r *CoaRepository) accountsMissing(coaid string, tags ...string) (Accounts, error) {
+	aa, err := r.AllAccounts(coaid)
+	if err != nil {
+		return nil, err
+	}
+	result := Accounts{}
+	for _, a := range aa {
+		if !a.Removed.IsZero() || len(a.Tags.Intersect(tags)) > 0 {
+			continue
+		}
+		result = append(result, a)
+	}
+	return result, nil
+}
@@ -0,0 +1,186 @@
+package coa
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidateDetailAndSummary(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	_, err = r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "detail", "summary"}})
+	if err == nil || err.Error() != "The account must be either detail or summary" {
+		t.Errorf("Expected detail and summary conflict but was %v", err)
+	}
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	if !a1.Postable() {
+		t.Errorf("Expected detail account to be postable")
+	}
+	issues, err := r.Validate(coa.Id)
+	check(t, err)
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues but was %v", issues)
+	}
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	accounts[0].Tags = append(accounts[0].Tags, "summary")
+	check(t, r.put("accounts/"+coa.Id, accounts))
+	issues, err = r.Validate(coa.Id)
+	check(t, err)
+	if len(issues) != 1 || issues[0].AccountId != a1.Id || issues[0].Message != "The account must be either detail or summary" {
+		t.Errorf("Expected detail and summary issue but was %v", issues)
+	}
+	if accounts[0].Postable() {
+		t.Errorf("Expected account tagged summary not to be postable")
+	}
+}
+
+func TestValidateAccounts(t *testing.T) {
+	accounts := Accounts{
+		&Account{Id: "a1", Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "summary"}},
+		&Account{Id: "a11", Number: "1.1", Name: "a1.1", Parent: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}},
+		&Account{Id: "a2", Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnCredit", "detail"}},
+	}
+	if issues := ValidateAccounts(accounts, "a2"); len(issues) != 0 {
+		t.Errorf("Expected no issues but was %v", issues)
+	}
+	accounts = append(accounts,
+		&Account{Id: "dup", Number: "2", Name: "dup", Tags: []string{"balanceSheet", "increaseOnCredit", "detail"}},
+		&Account{Id: "orphan", Number: "3.1", Name: "orphan", Parent: "a3", Tags: []string{"balanceSheet", "increaseOnCredit", "detail"}},
+		&Account{Id: "prefix", Number: "2.1", Name: "prefix", Parent: "a1", Tags: []string{"balanceSheet", "increaseOnDebit", "detail"}},
+		&Account{Id: "inherit", Number: "1.2", Name: "inherit", Parent: "a1", Tags: []string{"incomeStatement", "increaseOnDebit", "detail"}},
+		&Account{Id: "local", Number: "4", Name: "local", Tags: []string{"balanceSheet"}},
+	)
+	issues := ValidateAccounts(accounts, "gone")
+	expected := []ValidationIssue{
+		{"dup", "An account with this number already exists"},
+		{"orphan", "Parent not found: a3"},
+		{"prefix", "The number must start with parent's number"},
+		{"inherit", "The financial statement must be same as the parent"},
+		{"local", "The normal balance must be informed"},
+		{"", "Retained earnings account not found: gone"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %v but was %v", expected, issues)
+	}
+	for i := range expected {
+		if issues[i] != expected[i] {
+			t.Errorf("Expected %v but was %v", expected[i], issues[i])
+		}
+	}
+}
+
+func TestValidateAccountsChecksAncestors(t *testing.T) {
+	accounts := Accounts{
+		&Account{Id: "a5", Number: "5", Name: "a5", Tags: []string{"incomeStatement", "increaseOnDebit", "summary", "operating"}},
+		&Account{Id: "a51", Number: "5.1", Name: "a5.1", Parent: "a5", Tags: []string{"incomeStatement", "increaseOnDebit", "summary"}},
+		&Account{Id: "a511", Number: "5.1.1", Name: "a5.1.1", Parent: "a51", Tags: []string{"incomeStatement", "increaseOnDebit", "detail"}},
+	}
+	issues := ValidateAccounts(accounts, "")
+	expected := []ValidationIssue{
+		{"a51", "The income statement attribute must be same as the parent"},
+		{"a511", "The income statement attribute must be same as the ancestor 5"},
+	}
+	if len(issues) != len(expected) {
+		t.Fatalf("Expected %v but was %v", expected, issues)
+	}
+	for i := range expected {
+		if issues[i] != expected[i] {
+			t.Errorf("Expected %v but was %v", expected[i], issues[i])
+		}
+	}
+}
+
+func TestAccountsMissingClassification(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	check(t, r.put("accounts/"+coa.Id, Accounts{
+		&Account{Id: "a3", Number: "3", Name: "a3", Tags: []string{"increaseOnDebit"}},
+		&Account{Id: "a1", Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}},
+		&Account{Id: "a2", Number: "2", Name: "a2", Tags: []string{"detail"}},
+		&Account{Id: "a4", Number: "4", Name: "a4", Tags: []string{"incomeStatement"}},
+	}))
+	accounts, err := r.AccountsMissingStatement(coa.Id)
+	check(t, err)
+	if len(accounts) != 2 || accounts[0].Id != "a2" || accounts[1].Id != "a3" {
+		t.Errorf("Expected a2 and a3 but was %v", accounts)
+	}
+	accounts, err = r.AccountsMissingNormalBalance(coa.Id)
+	check(t, err)
+	if len(accounts) != 2 || accounts[0].Id != "a2" || accounts[1].Id != "a4" {
+		t.Errorf("Expected a2 and a4 but was %v", accounts)
+	}
+}
+
+func TestCheckInheritance(t *testing.T) {
+	a := &Account{Tags: []string{"incomeStatement", "increaseOnDebit"}}
+	ancestors := Accounts{
+		&Account{Tags: []string{"incomeStatement", "operating", "summary"}},
+		&Account{Tags: []string{"incomeStatement", "cost", "operating", "summary"}},
+	}
+	if missing := CheckInheritance(a, ancestors); len(missing) != 2 || missing[0] != "cost" || missing[1] != "operating" {
+		t.Errorf("Expected cost and operating but was %v", missing)
+	}
+	a.Tags = append(a.Tags, "cost", "operating")
+	if missing := CheckInheritance(a, ancestors); len(missing) != 0 {
+		t.Errorf("Expected nothing missing but was %v", missing)
+	}
+}
+
+func TestValidateBalanceSheetIncomeStatementAttribute(t *testing.T) {
+	accounts := Accounts{
+		&Account{Id: "a1", Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnCredit", "detail"}},
+		&Account{Id: "a2", Number: "2", Name: "a2", Tags: []string{"balanceSheet", "increaseOnDebit", "operating", "detail"}},
+	}
+	if issues := ValidateAccounts(accounts, ""); len(issues) != 0 {
+		t.Errorf("Expected no issues but was %v", issues)
+	}
+	issues := ValidateAccounts(accounts, "a1")
+	if len(issues) != 1 || issues[0].AccountId != "a2" || issues[0].Message != "A balance sheet account cannot have the income statement attribute operating" {
+		t.Errorf("Expected balance sheet attribute issue but was %v", issues)
+	}
+}
+
+func TestValidateChart(t *testing.T) {
+	r := NewCoaRepository(store{})
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	check(t, r.ValidateChart(coa.Id))
+	re, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "re", Tags: []string{"balanceSheet", "increaseOnCredit", "retainedEarnings"}})
+	check(t, err)
+	check(t, r.ValidateChart(coa.Id))
+	for _, tc := range []struct {
+		tags    []string
+		message string
+	}{
+		{[]string{"incomeStatement", "increaseOnCredit", "detail"}, "The retained earnings account must be a balance sheet account"},
+		{[]string{"balanceSheet", "increaseOnDebit", "detail"}, "The retained earnings account must increase on credit"},
+	} {
+		accounts, err := r.AllAccounts(coa.Id)
+		check(t, err)
+		accounts[0].Tags = tc.tags
+		check(t, r.put("accounts/"+coa.Id, accounts))
+		err = r.ValidateChart(coa.Id)
+		if err == nil || err.Error() != tc.message {
+			t.Errorf("Expected %v but was %v", tc.message, err)
+		}
+	}
+	accounts, err := r.AllAccounts(coa.Id)
+	check(t, err)
+	accounts[0].Removed = time.Now()
+	check(t, r.put("accounts/"+coa.Id, accounts))
+	if err := r.ValidateChart(coa.Id); !errors.Is(err, ErrRemoved) {
+		t.Errorf("Expected ErrRemoved but was %v", err)
+	}
+	check(t, r.put("accounts/"+coa.Id, Accounts{}))
+	if err := r.ValidateChart(coa.Id); !errors.Is(err, ErrDanglingReference) {
+		t.Errorf("Expected ErrDanglingReference for %v but was %v", re.Id, err)
+	}
+	if err := r.ValidateChart("unknown"); err == nil {
+		t.Error("Expected an error for an unknown chart")
+	}
+}
@@ -0,0 +1,80 @@
+package coa
+
+import (
+	"sync"
+	"time"
+)
+
+// msgp stores times as UTC instants and decodes them in the local zone, so
+// the zone of each time is recorded in Zones when storing and the time is
+// moved back into it when loading. Zones holds one entry per time field, in
+// declaration order: AsOf, Created, Removed and, for accounts, Reactivated.
+// A zone whose name can't be loaded, such as the fixed offsets time.Parse
+// returns, is rebuilt from its offset.
+
+func (a *Account) recordZone() {
+	a.Zones = recordZones(a.AsOf, a.Created, a.Removed, a.Reactivated)
+}
+
+func (a *Account) restoreZone() {
+	a.AsOf = restoreZone(a.AsOf, a.Zones, 0)
+	a.Created = restoreZone(a.Created, a.Zones, 1)
+	a.Removed = restoreZone(a.Removed, a.Zones, 2)
+	a.Reactivated = restoreZone(a.Reactivated, a.Zones, 3)
+}
+
+func (coa *ChartOfAccounts) recordZone() {
+	coa.Zones = recordZones(coa.AsOf, coa.Created, coa.Removed)
+}
+
+func (coa *ChartOfAccounts) restoreZone() {
+	coa.AsOf = restoreZone(coa.AsOf, coa.Zones, 0)
+	coa.Created = restoreZone(coa.Created, coa.Zones, 1)
+	coa.Removed = restoreZone(coa.Removed, coa.Zones, 2)
+}
+
+func recordZones(times ...time.Time) []TimeZone {
+	zones := make([]TimeZone, len(times))
+	for i, t := range times {
+		if !t.IsZero() {
+			name, offset := t.Zone()
+			zones[i] = TimeZone{Name: t.Location().String(), Offset: offset}
+			if zones[i].Name == "Local" {
+				zones[i].Name = name
+			}
+		}
+	}
+	return zones
+}
+
+func restoreZone(t time.Time, zones []TimeZone, i int) time.Time {
+	if t.IsZero() || i >= len(zones) {
+		return t
+	}
+	z := zones[i]
+	if loc := loadZone(z.Name); loc != nil {
+		if _, offset := t.In(loc).Zone(); offset == z.Offset {
+			return t.In(loc)
+		}
+	}
+	return t.In(time.FixedZone(z.Name, z.Offset))
+}
+
+// zoneCache maps zone names to the loaded *time.Location, or to nil when
+// the name can't be loaded.
+var zoneCache sync.Map
+
+func loadZone(name string) *time.Location {
+	if name == "" {
+		return nil
+	}
+	if loc, ok := zoneCache.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		loc = nil
+	}
+	zoneCache.Store(name, loc)
+	return loc
+}
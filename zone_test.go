@@ -0,0 +1,96 @@
+package coa
+
+import (
+	"strconv"
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func TestTimesKeepTheirZone(t *testing.T) {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	check(t, err)
+	now := time.Date(2017, 12, 31, 22, 30, 0, 0, loc)
+	for _, perAccount := range []bool{false, true} {
+		s := store{}
+		r := NewCoaRepositoryWithClock(s, fixedClock(now))
+		r.PerAccount = perAccount
+		coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+		check(t, err)
+		a, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Tags: []string{"balanceSheet", "increaseOnDebit"}})
+		check(t, err)
+		r = NewCoaRepository(s)
+		r.PerAccount = perAccount
+		coa, err = r.GetChartOfAccounts(coa.Id)
+		check(t, err)
+		if !coa.AsOf.Equal(now) || coa.AsOf.Location().String() != loc.String() {
+			t.Errorf("Expected %v but was %v", now, coa.AsOf)
+		}
+		if coa.Created.Location().String() != loc.String() {
+			t.Errorf("Expected %v but was %v", now, coa.Created)
+		}
+		a, err = r.GetAccount(coa.Id, a.Id)
+		check(t, err)
+		if !a.AsOf.Equal(now) || a.AsOf.Location().String() != loc.String() {
+			t.Errorf("Expected %v but was %v", now, a.AsOf)
+		}
+		if a.Created.Location().String() != loc.String() || a.AsOf.Day() != 31 {
+			t.Errorf("Expected %v but was %v", now, a.Created)
+		}
+	}
+}
+
+func TestEachTimeKeepsItsZone(t *testing.T) {
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	check(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	check(t, err)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, saoPaulo)
+	created := time.Date(2019, 6, 1, 9, 0, 0, 0, tokyo)
+	parsed, err := time.Parse(time.RFC3339, "2019-06-01T09:00:00+05:30")
+	check(t, err)
+	s := store{}
+	r := NewCoaRepositoryWithClock(s, fixedClock(now))
+	r.PreserveTimestamps = true
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	check(t, err)
+	a1, err := r.SaveAccount(coa.Id, &Account{Number: "1", Name: "a1", Created: created, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	a2, err := r.SaveAccount(coa.Id, &Account{Number: "2", Name: "a2", Created: parsed, Tags: []string{"balanceSheet", "increaseOnDebit"}})
+	check(t, err)
+	r = NewCoaRepository(s)
+	a1, err = r.GetAccount(coa.Id, a1.Id)
+	check(t, err)
+	if !a1.Created.Equal(created) || a1.Created.Location().String() != "Asia/Tokyo" || a1.AsOf.Location().String() != "America/Sao_Paulo" {
+		t.Errorf("Expected Created in Asia/Tokyo and AsOf in America/Sao_Paulo but was %v and %v", a1.Created, a1.AsOf)
+	}
+	a2, err = r.GetAccount(coa.Id, a2.Id)
+	check(t, err)
+	if _, offset := a2.Created.Zone(); !a2.Created.Equal(parsed) || offset != 5*60*60+30*60 {
+		t.Errorf("Expected %v but was %v", parsed, a2.Created)
+	}
+}
+
+func BenchmarkAllAccountsInZone(b *testing.B) {
+	loc, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		b.Fatal(err)
+	}
+	r := NewCoaRepositoryWithClock(store{}, fixedClock(time.Date(2020, 1, 2, 0, 0, 0, 0, loc)))
+	coa, err := r.SaveChartOfAccounts(&ChartOfAccounts{Name: "coa"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 1; i <= 100; i++ {
+		if _, err := r.SaveAccount(coa.Id, &Account{Number: strconv.Itoa(i), Name: "a", Tags: []string{"balanceSheet", "increaseOnDebit"}}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.AllAccounts(coa.Id); err != nil {
+			b.Fatal(err)
+		}
+	}
+}